@@ -33,7 +33,11 @@ and prints the template output.
 `,
 	Args: cobra.NoArgs,
 	Run: func(_ *cobra.Command, _ []string) {
-		context := options.GetVariables().Copy()
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
+		context := options.GetContext().Copy()
 
 		for _, buildArg := range dumpCmdFlags.buildArgs {
 			name, value, _ := strings.Cut(buildArg, "=")
@@ -19,16 +19,24 @@ var evalCmdFlags struct {
 	buildArgs []string
 }
 
+// evalEnvKey is the key the package environment is available under in `bldr eval` templates.
+const evalEnvKey = "Env"
+
 // evalCmd represents the eval command.
 var evalCmd = &cobra.Command{
 	Use:   "eval",
 	Short: "Evaluate a Go template using the variables defined in the vars.yaml and Pkgfile.",
 	Long: `This command prints the result of evaluating a Go template give as the argument.
  Variables are looked up for the target specified as the '--target' flag.'.
+ Package environment ('env' in the pkg.yaml) is available as '.Env', e.g. '{{ .Env.GOFLAGS }}'.
  `,
 	Args: cobra.ExactArgs(1),
 	Run: func(_ *cobra.Command, args []string) {
-		context := options.GetVariables().Copy()
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
+		context := options.GetContext().Copy()
 
 		for _, buildArg := range evalCmdFlags.buildArgs {
 			name, value, _ := strings.Cut(buildArg, "=")
@@ -56,7 +64,11 @@ var evalCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		if err = tmpl.Execute(os.Stdout, graph.Root.Pkg.Context); err != nil {
+		// package environment is kept separate, so that it doesn't shadow the variables
+		vars := graph.Root.Pkg.Context.Copy()
+		vars[evalEnvKey] = graph.Root.Pkg.Env
+
+		if err = tmpl.Execute(os.Stdout, vars); err != nil {
 			log.Fatal(err)
 		}
 	},
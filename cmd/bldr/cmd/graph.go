@@ -32,7 +32,11 @@ Typical usage:
 `,
 	Args: cobra.NoArgs,
 	Run: func(_ *cobra.Command, _ []string) {
-		context := options.GetVariables().Copy()
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
+		context := options.GetContext().Copy()
 
 		for _, buildArg := range graphCmdFlags.buildArgs {
 			name, value, _ := strings.Cut(buildArg, "=")
@@ -51,7 +55,7 @@ Typical usage:
 		}
 
 		packages.FilterInPlace(func(pkg *v1alpha2.Pkg) bool {
-			return pkg.Context["GRAPH_IGNORE"] != "true"
+			return pkg.Context.GetString("GRAPH_IGNORE") != "true"
 		})
 
 		var packageSet solver.PackageSet
@@ -8,11 +8,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/spf13/cobra"
 
+	"github.com/siderolabs/bldr/internal/pkg/constants"
 	"github.com/siderolabs/bldr/internal/pkg/environment"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
 )
 
 const defaultPlatform = (runtime.GOOS + "/" + runtime.GOARCH)
@@ -49,6 +52,24 @@ func Execute() {
 	}
 }
 
+// configureOptions applies platform definitions and default variables from the Pkgfile (if any) to the options.
+func configureOptions() error {
+	var pkgfile *v1alpha2.Pkgfile
+
+	contents, err := os.ReadFile(filepath.Join(pkgRoot, constants.Pkgfile))
+
+	switch {
+	case err == nil:
+		if pkgfile, err = v1alpha2.NewPkgfile(contents); err != nil {
+			return fmt.Errorf("error parsing %q: %w", constants.Pkgfile, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	return pkgfile.ConfigureOptions(options)
+}
+
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.PersistentFlags().BoolVarP(&debug, "debug", "", false, "Enable debug logging")
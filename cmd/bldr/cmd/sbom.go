@@ -24,9 +24,13 @@ and outputs a Software Bill of Materials (SBOM) for it in SPDX format.
 `,
 	Args: cobra.NoArgs,
 	Run: func(_ *cobra.Command, _ []string) {
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
 		loader := solver.FilesystemPackageLoader{
 			Root:    pkgRoot,
-			Context: options.GetVariables(),
+			Context: options.GetContext(),
 		}
 
 		packages, err := solver.NewPackages(&loader)
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,9 +22,9 @@ import (
 	"strings"
 
 	"github.com/cheggaaa/pb/v3"
+	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 
-	"github.com/siderolabs/bldr/internal/pkg/environment"
 	"github.com/siderolabs/bldr/internal/pkg/solver"
 	"github.com/siderolabs/bldr/internal/pkg/types"
 	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
@@ -46,6 +47,10 @@ then this command will try to bump every changed variable in the diff:
   git diff -U0 | bldr update
 `,
 	Run: func(_ *cobra.Command, args []string) {
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
 		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 		defer cancel()
 
@@ -63,7 +68,8 @@ then this command will try to bump every changed variable in the diff:
 }
 
 var updateCmdFlags struct {
-	buildArgs []string
+	buildArgs         []string
+	skipFailedMirrors bool
 }
 
 func diffUpdater(ctx context.Context) {
@@ -106,26 +112,28 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 
 	var (
 		affectedSources []v1alpha2.Source
-		sourceVars      = map[string][]byte{}
+		// checksumFiles are the files which set the checksums of each affected source
+		checksumFiles [][]string
+		sourceVars    = map[string][]byte{}
 	)
 
-	for _, targetPlatform := range []environment.Platform{
-		environment.LinuxAmd64,
-		environment.LinuxArm64,
-	} {
+	for _, targetPlatform := range options.AllPlatforms() {
 		options.TargetPlatform = targetPlatform
 
-		context := options.GetVariables().Copy()
+		buildArgs := types.Variables{}
 
 		for _, buildArg := range updateCmdFlags.buildArgs {
 			name, value, _ := strings.Cut(buildArg, "=")
 
-			context["BUILD_ARG_"+name] = value
+			buildArgs["BUILD_ARG_"+name] = value
 		}
 
+		provenance := options.GetProvenance()
+		provenance.Merge("build arg", buildArgs)
+
 		loaderHooked := solver.FilesystemPackageLoader{
 			Root:    pkgRoot,
-			Context: context,
+			Context: provenance.Variables().Copy(),
 			HookOnVariables: func(path string, vars types.Variables) {
 				if path == varsPath {
 					if _, ok := vars[varName]; ok {
@@ -139,8 +147,8 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 		}
 
 		loaderClean := solver.FilesystemPackageLoader{
-			Root:    pkgRoot,
-			Context: context,
+			Root:       pkgRoot,
+			Provenance: provenance,
 		}
 
 		packagesHooked, err := solver.NewPackages(&loaderHooked)
@@ -158,11 +166,23 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 		for pkgIdx, pkg := range packagesHooked.ToSet().Sorted() {
 			for stepIdx, step := range pkg.Pkg.Steps {
 				for sourceIdx, src := range step.Sources {
-					if strings.Contains(src.URL, phase1Repl) {
-						origSrc := cleanSorted[pkgIdx].Pkg.Steps[stepIdx].Sources[sourceIdx]
-
-						if !slices.Contains(affectedSources, origSrc) {
+					if slices.ContainsFunc(src.URLs(), func(url string) bool { return strings.Contains(url, phase1Repl) }) {
+						origPkg := cleanSorted[pkgIdx].Pkg
+						origSrc := origPkg.Steps[stepIdx].Sources[sourceIdx]
+
+						idx := slices.IndexFunc(affectedSources, func(affected v1alpha2.Source) bool {
+							return slices.Equal(affected.URLs(), origSrc.URLs()) && affected.SHA256 == origSrc.SHA256 && affected.SHA512 == origSrc.SHA512
+						})
+						if idx == -1 {
 							affectedSources = append(affectedSources, origSrc)
+							checksumFiles = append(checksumFiles, nil)
+							idx = len(affectedSources) - 1
+						}
+
+						for _, path := range findChecksumFiles(loaderClean.ContextProvenance(origPkg.BaseDir), origPkg.Context, origSrc) {
+							if !slices.Contains(checksumFiles[idx], path) {
+								checksumFiles[idx] = append(checksumFiles[idx], path)
+							}
 						}
 					}
 				}
@@ -174,12 +194,12 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 		fmt.Fprintf(os.Stderr, "affected source: %s (%s/%s)\n", src.URL, src.SHA256, src.SHA512)
 	}
 
-	newChecksums := make(map[v1alpha2.Source]v1alpha2.Source, len(affectedSources))
+	newChecksums := make([]v1alpha2.Source, len(affectedSources))
 
-	for _, src := range affectedSources {
+	for i, src := range affectedSources {
 		var err error
 
-		newChecksums[src], err = downloadAndChecksum(ctx, src.URL)
+		newChecksums[i], err = downloadMirrorsAndChecksum(ctx, src.URLs(), updateCmdFlags.skipFailedMirrors)
 		if err != nil {
 			log.Fatalf("error processing %q: %s", src.URL, err)
 		}
@@ -187,13 +207,25 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 
 	newContents := map[string][]byte{}
 
-	for oldSrc, newSrc := range newChecksums {
+	for i, oldSrc := range affectedSources {
+		newSrc := newChecksums[i]
+
 		fmt.Printf("updating %s, sha256 %s -> %s\n", oldSrc.URL, oldSrc.SHA256, newSrc.SHA256)
 
-		for path, contents := range sourceVars {
+		paths := checksumFiles[i]
+
+		if len(paths) == 0 {
+			// checksums are not traced back to a variable (e.g. set in the pkg.yaml),
+			// so fall back to replacing them in every loaded file
+			fmt.Fprintf(os.Stderr, "checksums of %s are not set by any variable, updating every variables file\n", oldSrc.URL)
+
+			paths = slices.Sorted(maps.Keys(sourceVars))
+		}
+
+		for _, path := range paths {
 			origContent, ok := newContents[path]
 			if !ok {
-				origContent = contents
+				origContent = sourceVars[path]
 			}
 
 			replacedContents := bytes.ReplaceAll(origContent, []byte(oldSrc.SHA256), []byte(newSrc.SHA256))
@@ -215,6 +247,93 @@ func singleVariableUpdater(ctx context.Context, varsPath, varName string) {
 	}
 }
 
+// findChecksumFiles returns the files which set the variables holding the checksums of the source for the package.
+//
+// Each platform might load a different set of files (e.g. `vars.linux-arm64.yaml`),
+// so only the files which set the value seen by the package are returned: the last file which set the variable,
+// and the files which appended to or merged into it afterwards. Lists and maps are searched recursively.
+func findChecksumFiles(provenance *types.Provenance, vars types.Variables, src v1alpha2.Source) []string {
+	var files []string
+
+	for name, value := range vars {
+		if !containsChecksum(value, src) {
+			continue
+		}
+
+		origins := provenance.Origins(name)
+
+		for i := len(origins) - 1; i >= 0; i-- {
+			if containsChecksum(origins[i].Value, src) && !slices.Contains(files, origins[i].Source) {
+				files = append(files, origins[i].Source)
+			}
+
+			if origins[i].Action == types.OriginSet {
+				break
+			}
+		}
+	}
+
+	return files
+}
+
+// containsChecksum checks whether the variable value (or any nested list item or map value) is a checksum of the source.
+func containsChecksum(value any, src v1alpha2.Source) bool {
+	switch v := value.(type) {
+	case string:
+		return v != "" && (v == src.SHA256 || v == src.SHA512)
+	case []any:
+		return slices.ContainsFunc(v, func(item any) bool { return containsChecksum(item, src) })
+	case map[string]any:
+		for _, item := range v {
+			if containsChecksum(item, src) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// downloadMirrorsAndChecksum downloads the source from every mirror and verifies that they all serve the same file.
+//
+// Every mirror should be reachable, unless skipFailed is set: then mirrors which fail to download are skipped,
+// but at least one of them should succeed.
+func downloadMirrorsAndChecksum(ctx context.Context, urls []string, skipFailed bool) (v1alpha2.Source, error) {
+	var (
+		result   v1alpha2.Source
+		multiErr *multierror.Error
+	)
+
+	for _, url := range urls {
+		src, err := downloadAndChecksum(ctx, url)
+		if err != nil {
+			multiErr = multierror.Append(multiErr, err)
+
+			if skipFailed {
+				fmt.Fprintf(os.Stderr, "skipping mirror: %s\n", err)
+			}
+
+			continue
+		}
+
+		if result.URL == "" {
+			result = src
+
+			continue
+		}
+
+		if src.SHA256 != result.SHA256 || src.SHA512 != result.SHA512 {
+			return result, fmt.Errorf("mirror %q serves different contents than %q: sha256 %s != %s", url, result.URL, src.SHA256, result.SHA256)
+		}
+	}
+
+	if result.URL == "" || !skipFailed {
+		return result, multiErr.ErrorOrNil()
+	}
+
+	return result, nil
+}
+
 func downloadAndChecksum(ctx context.Context, url string) (v1alpha2.Source, error) {
 	fmt.Fprintf(os.Stderr, "downloading %s\n", url)
 
@@ -255,5 +374,6 @@ func downloadAndChecksum(ctx context.Context, url string) (v1alpha2.Source, erro
 
 func init() {
 	updateCmd.Flags().StringSliceVar(&updateCmdFlags.buildArgs, "build-arg", nil, "Build arguments to pass similar to docker buildx")
+	updateCmd.Flags().BoolVar(&updateCmdFlags.skipFailedMirrors, "skip-failed-mirrors", false, "Skip mirrors which fail to download instead of failing")
 	rootCmd.AddCommand(updateCmd)
 }
@@ -33,11 +33,18 @@ func validateChecksums(ctx context.Context, set solver.PackageSet, l *log.Logger
 			for pkg := range pkgs {
 				for _, step := range pkg.Steps {
 					for _, src := range step.Sources {
-						l.Printf("downloading %s ...", src.URL)
+						if src.IsGit() {
+							// git sources are pinned by commit and verified by buildkit on checkout
+							continue
+						}
+
+						for _, url := range src.URLs() {
+							l.Printf("downloading %s ...", url)
 
-						_, _, err := src.ValidateChecksums(ctx)
-						if err != nil {
-							errors <- fmt.Errorf("%s: %w", pkg.Name, err)
+							_, _, err := src.ValidateChecksumsFrom(ctx, url)
+							if err != nil {
+								errors <- fmt.Errorf("%s: %s: %w", pkg.Name, url, err)
+							}
 						}
 					}
 				}
@@ -84,9 +91,13 @@ var validateCmd = &cobra.Command{
 loads them and validates for errors. `,
 	Args: cobra.NoArgs,
 	Run: func(_ *cobra.Command, _ []string) {
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
 		loader := solver.FilesystemPackageLoader{
 			Root:    pkgRoot,
-			Context: options.GetVariables(),
+			Context: options.GetContext(),
 		}
 
 		packages, err := solver.NewPackages(&loader)
@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/siderolabs/bldr/internal/pkg/solver"
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+var varsCmdFlags struct {
+	explain   string
+	buildArgs []string
+}
+
+// varsCmd represents the vars command.
+var varsCmd = &cobra.Command{
+	Use:   "vars",
+	Short: "Print the variables of the package and the sources which set them.",
+	Long: `This command prints the variables (template context) of the target specified as the '--target' flag.
+ For each variable, every source which set or overrode it is listed in the order of merging.
+
+ With '--explain NAME', only the variable NAME is printed.
+ `,
+	Args: cobra.NoArgs,
+	Run: func(_ *cobra.Command, _ []string) {
+		if err := configureOptions(); err != nil {
+			log.Fatal(err)
+		}
+
+		buildArgs := types.Variables{}
+
+		for _, buildArg := range varsCmdFlags.buildArgs {
+			name, value, _ := strings.Cut(buildArg, "=")
+
+			buildArgs["BUILD_ARG_"+name] = value
+		}
+
+		provenance := options.GetProvenance()
+		provenance.Merge("build arg", buildArgs)
+
+		loader := solver.FilesystemPackageLoader{
+			Root:       pkgRoot,
+			Provenance: provenance,
+		}
+
+		packages, err := solver.NewPackages(&loader)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		graph, err := packages.Resolve(options.Target)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		pkg := graph.Root.Pkg
+		pkgProvenance := loader.ContextProvenance(pkg.BaseDir)
+
+		names := slices.Sorted(maps.Keys(pkg.Context))
+
+		if varsCmdFlags.explain != "" {
+			if _, ok := pkg.Context[varsCmdFlags.explain]; !ok {
+				log.Fatalf("variable %q is not defined for %q", varsCmdFlags.explain, pkg.Name)
+			}
+
+			names = []string{varsCmdFlags.explain}
+		}
+
+		for _, name := range names {
+			fmt.Printf("%s = %s\n", name, formatVariable(pkg.Context[name]))
+
+			for _, origin := range pkgProvenance.Origins(name) {
+				fmt.Printf("  %s %s: %s\n", origin.Action, origin.Source, formatVariable(origin.Value))
+			}
+		}
+	},
+}
+
+// formatVariable prints scalars as is, and lists and maps as JSON.
+func formatVariable(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(out)
+}
+
+func init() {
+	varsCmd.Flags().StringVarP(&options.Target, "target", "t", "", "Target image to build")
+	varsCmd.Flags().StringVar(&varsCmdFlags.explain, "explain", "", "Print only the variable with the given name")
+	varsCmd.Flags().StringSliceVar(&varsCmdFlags.buildArgs, "build-arg", nil, "Build arguments to pass similar to docker buildx")
+	varsCmd.MarkFlagRequired("target") //nolint:errcheck
+	varsCmd.Flags().Var(&options.BuildPlatform, "build-platform", "Build platform")
+	varsCmd.Flags().Var(&options.TargetPlatform, "target-platform", "Target platform")
+	rootCmd.AddCommand(varsCmd)
+}
@@ -10,6 +10,7 @@ require (
 	github.com/anchore/syft v1.51.0
 	github.com/cheggaaa/pb/v3 v3.2.0
 	github.com/containerd/platforms v1.0.0-rc.4
+	github.com/docker/go-units v0.5.0
 	github.com/emicklei/dot v1.11.0
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/moby/buildkit v0.32.2
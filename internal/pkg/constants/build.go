@@ -22,12 +22,27 @@ const PkgYaml = "pkg.yaml"
 // VarsYaml is the filename of 'vars.yaml'.
 const VarsYaml = "vars.yaml"
 
+// VarsPlatformYamlGlob matches the filenames of platform-specific 'vars.<os>-<arch>[-<variant>].yaml'.
+const VarsPlatformYamlGlob = "vars.*.yaml"
+
 // Pkgfile is the filename of 'Pkgfile'.
 const Pkgfile = "Pkgfile"
 
 // TemplateExt extension.
 const TemplateExt = ".tmpl"
 
+// PatchExt is the extension of patch files.
+const PatchExt = ".patch"
+
+// DiffExt is the alternative extension of patch files.
+const DiffExt = ".diff"
+
 // StageXBusyboxImage is the image name for busybox from stageX.
 // renovate: datasource=docker versioning=docker depName=siderolabs/stagex/core-busybox
 const StageXBusyboxImage = "ghcr.io/siderolabs/stagex/core-busybox:1.36.1@sha256:c0b551b47d8f1ac2fd5f4712eafddb8717e6e563a47203e02f94f944f64c18b2"
+
+// TargetPlatformVariable is the name of the variable which holds target platform (e.g. linux/amd64).
+const TargetPlatformVariable = "TARGETPLATFORM"
+
+// BuildPlatformVariable is the name of the variable which holds build platform (e.g. linux/amd64).
+const BuildPlatformVariable = "BUILDPLATFORM"
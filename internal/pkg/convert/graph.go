@@ -22,11 +22,13 @@ import (
 type GraphLLB struct {
 	*solver.PackageGraph
 	solverFn SolverFunc
+	probeFn  ProbeFunc
 
 	Options *environment.Options
 
 	BaseImages   map[v1alpha2.Variant]llb.State
 	Checksummer  llb.State
+	Fetcher      llb.State
 	LocalContext llb.State
 
 	baseImageProcessor llbProcessor
@@ -38,11 +40,14 @@ type GraphLLB struct {
 type llbProcessor func(llb.State) llb.State
 
 // NewGraphLLB creates new GraphLLB and initializes shared images.
-func NewGraphLLB(graph *solver.PackageGraph, solverFn SolverFunc, options *environment.Options) *GraphLLB {
+//
+// If probeFn is nil, sources are always downloaded from the primary URL.
+func NewGraphLLB(graph *solver.PackageGraph, solverFn SolverFunc, probeFn ProbeFunc, options *environment.Options) *GraphLLB {
 	result := &GraphLLB{
 		PackageGraph: graph,
 		Options:      options,
 		solverFn:     solverFn,
+		probeFn:      probeFn,
 		cache:        make(map[*solver.PackageNode]llb.State),
 	}
 
@@ -52,6 +57,7 @@ func NewGraphLLB(graph *solver.PackageGraph, solverFn SolverFunc, options *envir
 
 	result.buildBaseImages()
 	result.buildChecksummer()
+	result.buildFetcher()
 	result.buildLocalContext()
 
 	return result
@@ -68,7 +74,8 @@ func (graph *GraphLLB) buildBaseImages() {
 	}
 
 	addEnv := func(root llb.State) llb.State {
-		vars := graph.Options.GetVariables()
+		// only scalar variables are exported to the environment
+		vars := graph.Options.GetVariables().Environment()
 		keys := make([]string, 0, len(vars))
 
 		for key := range vars {
@@ -87,6 +94,13 @@ func (graph *GraphLLB) buildBaseImages() {
 	graph.baseImageProcessor = func(root llb.State) llb.State {
 		return addEnv(addPkg(root))
 	}
+}
+
+// baseImage returns (building on first use) the base image for the variant.
+func (graph *GraphLLB) baseImage(variant v1alpha2.Variant, def v1alpha2.VariantDefinition) llb.State {
+	if root, ok := graph.BaseImages[variant]; ok {
+		return root
+	}
 
 	// Pin the base image to the build platform, like `FROM --platform=$BUILDPLATFORM`.
 	// This stamps the build platform onto every op derived from the base (RUN,
@@ -96,24 +110,36 @@ func (graph *GraphLLB) buildBaseImages() {
 	// while dependencies pinned to the target platform are pulled as-is.
 	buildPlatform := graph.Options.BuildPlatform.PlatformSpec
 
-	graph.BaseImages[v1alpha2.Alpine] = graph.baseImageProcessor(llb.Image(
-		constants.DefaultBaseImage,
-		llb.WithCustomName(graph.Options.CommonPrefix+"base"),
-	).Platform(buildPlatform).Run(
-		append(
-			graph.commonRunOptions,
-			llb.Shlex("apk --no-cache --update add bash"),
-			llb.WithCustomName(graph.Options.CommonPrefix+"base-apkinstall"),
-		)...,
-	).Run(
-		append(
-			graph.commonRunOptions,
-			llb.Args([]string{"ln", "-svf", "/bin/bash", "/bin/sh"}),
-			llb.WithCustomName(graph.Options.CommonPrefix+"base-symlink"),
-		)...,
-	).Root())
-
-	graph.BaseImages[v1alpha2.Scratch] = graph.baseImageProcessor(llb.Scratch().Platform(buildPlatform))
+	var root llb.State
+
+	if def.Image == "" {
+		root = llb.Scratch().Platform(buildPlatform)
+	} else {
+		prefix := graph.Options.CommonPrefix + "base"
+		if variant != v1alpha2.Alpine {
+			prefix += "-" + variant.String()
+		}
+
+		root = llb.Image(
+			def.Image,
+			llb.WithCustomName(prefix),
+		).Platform(buildPlatform)
+
+		for i, command := range def.Setup {
+			root = root.Run(
+				append(
+					graph.commonRunOptions,
+					llb.Shlex(command),
+					llb.WithCustomNamef("%s-setup-%d", prefix, i),
+				)...,
+			).Root()
+		}
+	}
+
+	root = graph.baseImageProcessor(root)
+	graph.BaseImages[variant] = root
+
+	return root
 }
 
 func (graph *GraphLLB) buildChecksummer() {
@@ -126,16 +152,30 @@ func (graph *GraphLLB) buildChecksummer() {
 	).Platform(graph.Options.BuildPlatform.PlatformSpec)
 }
 
+func (graph *GraphLLB) buildFetcher() {
+	// Fetcher downloads sources from the mirrors if the primary URL fails (buildkit HTTP source has no fallback),
+	// Alpine ships busybox wget with TLS support and CA certificates, so the (possibly pinned) `alpine` variant image is used.
+	graph.Fetcher = llb.Image(
+		graph.FetcherImage,
+		llb.WithCustomName(graph.Options.CommonPrefix+"fetcher"),
+	).Platform(graph.Options.BuildPlatform.PlatformSpec)
+}
+
 func (graph *GraphLLB) buildLocalContext() {
+	excludes := []string{
+		"**/.*",
+		"**/" + constants.PkgYaml,
+		"**/" + constants.VarsYaml,
+	}
+
+	// only platform vars.yaml files are excluded, other vars.*.yaml files are kept
+	for _, platform := range graph.Options.AllPlatforms() {
+		excludes = append(excludes, "**/"+environment.VarsYaml(platform.ID))
+	}
+
 	graph.LocalContext = llb.Local(
 		"context",
-		llb.ExcludePatterns(
-			[]string{
-				"**/.*",
-				"**/" + constants.PkgYaml,
-				"**/" + constants.VarsYaml,
-			},
-		),
+		llb.ExcludePatterns(excludes),
 		llb.ExcludePatterns([]string{
 			"_out/",
 		}),
@@ -145,7 +185,7 @@ func (graph *GraphLLB) buildLocalContext() {
 
 // Build converts package graph to LLB.
 func (graph *GraphLLB) Build(ctx context.Context) (llb.State, error) {
-	return NewNodeLLB(graph.Root, graph).Build(ctx)
+	return NewNodeLLB(graph.Root, graph).Build(ctx, graph.Output)
 }
 
 // Marshal returns marshaled LLB.
@@ -0,0 +1,231 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package convert
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+// repositoryFile is a file configuring the extra package repository.
+type repositoryFile struct {
+	Path    string
+	Content string
+}
+
+// packageManager converts the install section of the package to LLB.
+type packageManager interface {
+	// repositoryFiles returns the files to configure the repository (key, repository config).
+	repositoryFiles(repo v1alpha2.Repository) []repositoryFile
+	// commands returns the commands to install the packages.
+	commands(packages []string, repos []v1alpha2.Repository) [][]string
+	// cacheDirs returns the directories which keep the package index and downloaded packages between builds.
+	cacheDirs() []string
+	// env returns the environment variables for the commands.
+	env() map[string]string
+}
+
+var packageManagers = map[v1alpha2.PackageManager]packageManager{
+	v1alpha2.PackageManagerAPK: apk{},
+	v1alpha2.PackageManagerAPT: apt{},
+	v1alpha2.PackageManagerDNF: dnf{},
+}
+
+// getPackageManager returns the package manager of the variant.
+func getPackageManager(def v1alpha2.VariantDefinition) (packageManager, bool) {
+	if def.PackageManager == v1alpha2.PackageManagerCustom {
+		return custom{install: def.Install}, true
+	}
+
+	pm, ok := packageManagers[def.PackageManager]
+
+	return pm, ok
+}
+
+type apk struct{}
+
+func (apk) repositoryFiles(repo v1alpha2.Repository) []repositoryFile {
+	// apk picks the key by the name, so the repository should be named after the key
+	return []repositoryFile{
+		{Path: "/etc/apk/keys/" + repo.Name + ".rsa.pub", Content: repo.Key},
+	}
+}
+
+func (apk) commands(packages []string, repos []v1alpha2.Repository) [][]string {
+	// the index is kept in the cache mount, so refreshing it is cheap
+	args := []string{"/sbin/apk", "add", "--update", "--cache-dir", "/var/cache/apk"}
+
+	for _, repo := range repos {
+		args = append(args, "--repository", repo.URL)
+	}
+
+	return [][]string{append(args, packages...)}
+}
+
+func (apk) cacheDirs() []string {
+	return []string{"/var/cache/apk"}
+}
+
+func (apk) env() map[string]string {
+	return nil
+}
+
+type apt struct{}
+
+func (apt) repositoryFiles(repo v1alpha2.Repository) []repositoryFile {
+	keyPath := "/etc/apt/keyrings/" + repo.Name + ".asc"
+
+	return []repositoryFile{
+		{Path: keyPath, Content: repo.Key},
+		{
+			Path: "/etc/apt/sources.list.d/" + repo.Name + ".list",
+			Content: fmt.Sprintf("deb [signed-by=%s] %s %s %s\n",
+				keyPath, repo.URL, repo.Suite, strings.Join(repo.GetComponents(), " "),
+			),
+		},
+	}
+}
+
+func (apt) commands(packages []string, _ []v1alpha2.Repository) [][]string {
+	return [][]string{
+		{"apt-get", "update"},
+		append([]string{"apt-get", "install", "-y", "--no-install-recommends"}, packages...),
+	}
+}
+
+func (apt) cacheDirs() []string {
+	return []string{"/var/lib/apt/lists", "/var/cache/apt"}
+}
+
+func (apt) env() map[string]string {
+	return map[string]string{"DEBIAN_FRONTEND": "noninteractive"}
+}
+
+type dnf struct{}
+
+func (dnf) repositoryFiles(repo v1alpha2.Repository) []repositoryFile {
+	keyPath := "/etc/pki/rpm-gpg/RPM-GPG-KEY-" + repo.Name
+
+	return []repositoryFile{
+		{Path: keyPath, Content: repo.Key},
+		{
+			Path: "/etc/yum.repos.d/" + repo.Name + ".repo",
+			Content: fmt.Sprintf("[%s]\nname=%s\nbaseurl=%s\nenabled=1\ngpgcheck=1\ngpgkey=file://%s\n",
+				repo.Name, repo.Name, repo.URL, keyPath,
+			),
+		},
+	}
+}
+
+func (dnf) commands(packages []string, _ []v1alpha2.Repository) [][]string {
+	// dnf pins versions with `name-version`
+	pinned := make([]string, 0, len(packages))
+
+	for _, spec := range packages {
+		if name, version := v1alpha2.SplitPackage(spec); version != "" {
+			spec = name + "-" + version
+		}
+
+		pinned = append(pinned, spec)
+	}
+
+	return [][]string{
+		append([]string{"dnf", "install", "-y", "--refresh", "--setopt=keepcache=True"}, pinned...),
+	}
+}
+
+func (dnf) cacheDirs() []string {
+	return []string{"/var/cache/dnf", "/var/cache/libdnf5"}
+}
+
+func (dnf) env() map[string]string {
+	return nil
+}
+
+// custom runs the install command of the variant, it doesn't support repositories and keeps no cache.
+type custom struct {
+	install []string
+}
+
+func (custom) repositoryFiles(v1alpha2.Repository) []repositoryFile {
+	return nil
+}
+
+func (pm custom) commands(packages []string, _ []v1alpha2.Repository) [][]string {
+	return [][]string{slices.Concat(pm.install, packages)}
+}
+
+func (custom) cacheDirs() []string {
+	return nil
+}
+
+func (custom) env() map[string]string {
+	return nil
+}
+
+func (node *NodeLLB) install(root llb.State) llb.State {
+	install := node.Pkg.Install
+
+	if len(install.Packages) == 0 {
+		return root
+	}
+
+	pm, ok := getPackageManager(node.Variant)
+	if !ok { // rejected by the solver
+		return root
+	}
+
+	for _, repo := range install.Repositories {
+		for _, file := range pm.repositoryFiles(repo) {
+			root = root.File(
+				llb.Mkdir(path.Dir(file.Path), constants.DefaultDirMode, llb.WithParents(true)).
+					Mkfile(file.Path, 0o644, []byte(file.Content)),
+				llb.WithCustomNamef(node.Prefix+"mkfile %s", file.Path),
+			)
+		}
+	}
+
+	runOptions := append([]llb.RunOption{}, node.Graph.commonRunOptions...)
+
+	env := pm.env()
+
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		runOptions = append(runOptions, llb.AddEnv(key, env[key]))
+	}
+
+	// package index and downloaded packages are kept between the builds, the index is platform-specific
+	for _, dir := range pm.cacheDirs() {
+		runOptions = append(runOptions,
+			llb.AddMount(
+				dir,
+				llb.Scratch(),
+				llb.AsPersistentCacheDir(
+					path.Clean(node.Graph.Options.CacheIDNamespace+"/"+node.Pkg.Variant.String()+"/"+node.Graph.Options.BuildPlatform.ID+dir),
+					llb.CacheMountLocked,
+				),
+			),
+		)
+	}
+
+	for _, args := range pm.commands(install.Packages, install.Repositories) {
+		root = root.Run(
+			append(
+				runOptions,
+				llb.Args(args),
+				llb.WithCustomNamef("%s%s-install %s", node.Prefix, node.Variant.PackageManager, strings.Join(args, " ")),
+			)...,
+		).Root()
+	}
+
+	return root
+}
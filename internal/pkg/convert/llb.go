@@ -17,7 +17,12 @@ import (
 // SolverFunc can be called to solve the package into the llb state via buildkit.
 type SolverFunc func(ctx context.Context, platform environment.Platform, target string) (*client.Result, error)
 
+// ProbeFunc can be called to check whether the llb state can be solved via buildkit.
+type ProbeFunc func(ctx context.Context, state llb.State) error
+
 // MarshalLLB translates package graph into LLB DAG and marshals it.
-func MarshalLLB(ctx context.Context, graph *solver.PackageGraph, solver SolverFunc, options *environment.Options) (*llb.Definition, error) {
-	return NewGraphLLB(graph, solver, options).Marshal(ctx)
+func MarshalLLB(
+	ctx context.Context, graph *solver.PackageGraph, solver SolverFunc, probe ProbeFunc, options *environment.Options,
+) (*llb.Definition, error) {
+	return NewGraphLLB(graph, solver, probe, options).Marshal(ctx)
 }
@@ -10,6 +10,8 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/frontend/gateway/client"
@@ -38,6 +40,34 @@ const (
 	// stage, so BuildKit recursively walks the entire rootfs of the build container just to
 	// hash a directory which is always empty.
 	emptyDir = "/.bldr/empty"
+
+	// downloadDir is the mount point for downloads performed by the fetcher.
+	downloadDir = "/download"
+
+	// patchDir is the mount point for the directory being patched.
+	patchDir = "/src"
+
+	// fetchScript downloads the source trying each mirror in order until checksums match.
+	//
+	// It is only used if the primary URL fails.
+	//
+	// Arguments: destination, URLs...; expected checksums are passed via environment.
+	fetchScript = `set -eu
+dest="` + downloadDir + `/$1"
+shift
+mkdir -p "$(dirname "$dest")"
+for url in "$@"; do
+  echo "fetching $url"
+  if wget -q -O "$dest" "$url" &&
+    echo "$SHA256  $dest" | sha256sum -c -s &&
+    echo "$SHA512  $dest" | sha512sum -c -s; then
+    exit 0
+  fi
+  echo "failed to fetch $url or checksums mismatch"
+  rm -f "$dest"
+done
+exit 1
+`
 )
 
 func defaultCopyOptions(options *environment.Options, reproducible bool) *llb.CopyInfo {
@@ -71,6 +101,8 @@ type NodeLLB struct {
 
 	Graph  *GraphLLB
 	Prefix string
+
+	mountStates map[string]llb.State
 }
 
 // NewNodeLLB wraps PackageNode for LLB conversion.
@@ -84,23 +116,7 @@ func NewNodeLLB(node *solver.PackageNode, graph *GraphLLB) *NodeLLB {
 }
 
 func (node *NodeLLB) base() llb.State {
-	return node.Graph.BaseImages[node.Pkg.Variant]
-}
-
-func (node *NodeLLB) install(root llb.State) llb.State {
-	if len(node.Pkg.Install) > 0 {
-		root = root.Run(
-			append(
-				node.Graph.commonRunOptions,
-				llb.Args(
-					append([]string{"/sbin/apk", "add", "--no-cache"}, node.Pkg.Install...),
-				),
-				llb.WithCustomName(node.Prefix+"apk-install"),
-			)...,
-		).Root()
-	}
-
-	return root
+	return node.Graph.baseImage(node.Pkg.Variant, node.Variant)
 }
 
 func (node *NodeLLB) context(root llb.State) llb.State {
@@ -138,7 +154,7 @@ func (node *NodeLLB) convertDependency(ctx context.Context, dep solver.PackageDe
 		// single-platform (build == target) context at that platform.
 		depPlatform := node.Graph.Options.BuildPlatform.ID
 		if dep.Platform != "" {
-			depPlatform = dep.Platform
+			depPlatform = environment.NormalizeID(dep.Platform)
 		}
 
 		// Building inline shares the current graph's options, which is only
@@ -149,19 +165,21 @@ func (node *NodeLLB) convertDependency(ctx context.Context, dep solver.PackageDe
 		crossBuild := node.Graph.Options.BuildPlatform.ID != node.Graph.Options.TargetPlatform.ID
 
 		if !crossBuild && depPlatform == node.Graph.Options.BuildPlatform.ID {
-			depState, err = NewNodeLLB(dep.Node, node.Graph).Build(ctx)
+			depState, err = NewNodeLLB(dep.Node, node.Graph).Build(ctx, dep.Output())
 			if err != nil {
 				return llb.Scratch(), "", err
 			}
 		} else {
-			platform, ok := environment.Platforms[depPlatform]
-			if !ok {
-				return llb.Scratch(), "", fmt.Errorf("platform %q not supported", depPlatform)
+			var platform environment.Platform
+
+			platform, err = node.Graph.Options.GetPlatform(depPlatform)
+			if err != nil {
+				return llb.Scratch(), "", err
 			}
 
 			var res *client.Result
 
-			res, err = node.Graph.solverFn(ctx, platform, dep.Node.Name)
+			res, err = node.Graph.solverFn(ctx, platform, dep.Stage)
 			if err != nil {
 				return llb.Scratch(), "", err
 			}
@@ -179,15 +197,17 @@ func (node *NodeLLB) convertDependency(ctx context.Context, dep solver.PackageDe
 			}
 		}
 
-		srcName = dep.Node.Name
+		srcName = dep.Stage
 	} else {
 		depState = llb.Image(dep.Image)
 		srcName = dep.Image
 
 		if dep.Platform != "" {
-			platform, ok := environment.Platforms[dep.Platform]
-			if !ok {
-				return llb.Scratch(), "", fmt.Errorf("platform %q not supported", dep.Platform)
+			var platform environment.Platform
+
+			platform, err = node.Graph.Options.GetPlatform(dep.Platform)
+			if err != nil {
+				return llb.Scratch(), "", err
 			}
 
 			depState = llb.Image(dep.Image, platform.LLBPlatform)
@@ -230,14 +250,18 @@ func (node *NodeLLB) dependencies(ctx context.Context, root llb.State) (llb.Stat
 			return llb.Scratch(), err
 		}
 
-		if dep.Src() == "/" && dep.Dest() == "/" {
+		if dep.Src() == "/" && dep.Dest() == "/" && !dep.HasFilters() {
 			// skip copying if the source and destination are "/"
 			stages = append(stages, depState)
 		} else {
+			copyOptions := defaultCopyOptions(node.Graph.Options, false)
+			copyOptions.IncludePatterns = dep.Include
+			copyOptions.ExcludePatterns = dep.Exclude
+
 			stages = append(
 				stages,
 				llb.Scratch().File(
-					llb.Copy(depState, dep.Src(), dep.Dest(), defaultCopyOptions(node.Graph.Options, false)),
+					llb.Copy(depState, dep.Src(), dep.Dest(), copyOptions),
 					llb.WithCustomNamef(node.Prefix+"copy --from %s %s -> %s", srcName, dep.Src(), dep.Dest()),
 				),
 			)
@@ -251,18 +275,86 @@ func (node *NodeLLB) dependencies(ctx context.Context, root llb.State) (llb.Stat
 	return root.WithOutput(llb.Merge(stages, llb.WithCustomName(node.Prefix+"copy")).Output()), nil
 }
 
+func (node *NodeLLB) mounts(ctx context.Context) error {
+	node.mountStates = make(map[string]llb.State, len(node.Mounts))
+
+	for _, dep := range node.Mounts {
+		depState, _, err := node.convertDependency(ctx, dep)
+		if err != nil {
+			return err
+		}
+
+		node.mountStates[dep.ID()] = depState
+	}
+
+	return nil
+}
+
+func (node *NodeLLB) mountRunOption(mount v1alpha2.Mount) llb.RunOption {
+	switch mount.Type {
+	case v1alpha2.MountTypeTmpfs:
+		var tmpfsOptions []llb.TmpfsOption
+
+		if size := mount.SizeBytes(); size > 0 {
+			tmpfsOptions = append(tmpfsOptions, llb.TmpfsSize(size))
+		}
+
+		return llb.AddMount(mount.Target, llb.Scratch(), llb.Tmpfs(tmpfsOptions...))
+	case v1alpha2.MountTypeCache:
+		sharing := map[v1alpha2.CacheSharing]llb.CacheMountSharingMode{
+			v1alpha2.CacheSharingShared:  llb.CacheMountShared,
+			v1alpha2.CacheSharingPrivate: llb.CacheMountPrivate,
+			v1alpha2.CacheSharingLocked:  llb.CacheMountLocked,
+		}[mount.Sharing]
+
+		return llb.AddMount(
+			mount.Target,
+			llb.Scratch(),
+			llb.AsPersistentCacheDir(path.Clean(node.Graph.Options.CacheIDNamespace+"/"+mount.GetID()), sharing),
+		)
+	case v1alpha2.MountTypeBind, v1alpha2.MountTypeUnset: // unset type is rejected by validation
+	}
+
+	dep, _ := node.MountDependency(mount) // bind mounts are resolved by the solver
+
+	return llb.AddMount(mount.Target, node.mountStates[dep.ID()], llb.SourcePath(mount.GetSource()), llb.Readonly)
+}
+
 func (node *NodeLLB) stepTmpDir(root llb.State, step *v1alpha2.Step) llb.State {
-	if step.TmpDir == "" {
-		step.TmpDir = tmpDir
+	if step.WorkDir == "" {
+		step.WorkDir = tmpDir
+	}
+
+	mkdirOptions := []llb.MkdirOption{llb.WithParents(true)}
+
+	if step.User != "" {
+		mkdirOptions = append(mkdirOptions, llb.WithUIDGID(step.UIDGID()))
 	}
 
 	return root.File(
-		llb.Mkdir(step.TmpDir, constants.DefaultDirMode, llb.WithParents(true)),
-		llb.WithCustomName(node.Prefix+"mkdir "+step.TmpDir),
-	).Dir(step.TmpDir)
+		llb.Mkdir(step.WorkDir, constants.DefaultDirMode, mkdirOptions...),
+		llb.WithCustomName(node.Prefix+"mkdir "+step.WorkDir),
+	).Dir(step.WorkDir)
 }
 
-func (node *NodeLLB) stepDownload(root llb.State, step v1alpha2.Step) llb.State {
+// stepCopyOptions returns copy options for the files placed into the step working directory,
+// so that they are owned by the step user.
+func (node *NodeLLB) stepCopyOptions(step v1alpha2.Step) *llb.CopyInfo {
+	copyOptions := defaultCopyOptions(node.Graph.Options, false)
+
+	if step.User != "" {
+		uid, gid := step.UIDGID()
+
+		copyOptions.ChownOpt = &llb.ChownOpt{
+			User:  &llb.UserOpt{UID: uid},
+			Group: &llb.UserOpt{UID: gid},
+		}
+	}
+
+	return copyOptions
+}
+
+func (node *NodeLLB) stepDownload(ctx context.Context, root llb.State, step v1alpha2.Step) llb.State {
 	if len(step.Sources) == 0 {
 		return root
 	}
@@ -270,43 +362,171 @@ func (node *NodeLLB) stepDownload(root llb.State, step v1alpha2.Step) llb.State
 	stages := []llb.State{root}
 
 	for _, source := range step.Sources {
-		download := llb.HTTP(
-			source.URL,
-			llb.Header(llb.HTTPHeader{Accept: "*/*", UserAgent: fmt.Sprintf("BLDR/%s", version.Tag)}),
-			llb.Filename(filepath.Join("/", source.Destination)),
-			llb.Checksum(digest.NewDigestFromEncoded(digest.SHA256, source.SHA256)),
-			llb.WithCustomNamef(node.Prefix+"download %s -> %s", source.URL, source.Destination),
-		)
+		switch {
+		case source.IsGit():
+			stages = append(stages, node.downloadGit(step, source))
+		case len(source.Mirrors) > 0 && !node.probeHTTP(ctx, source):
+			stages = append(stages, node.downloadMirrors(step, source))
+		default:
+			stages = append(stages, node.downloadHTTP(step, source))
+		}
+	}
+
+	return root.WithOutput(llb.Merge(stages, llb.WithCustomName(node.Prefix+"download")).Output())
+}
+
+func (node *NodeLLB) httpSource(source v1alpha2.Source) llb.State {
+	return llb.HTTP(
+		source.URL,
+		llb.Header(llb.HTTPHeader{Accept: "*/*", UserAgent: fmt.Sprintf("BLDR/%s", version.Tag)}),
+		llb.Filename(filepath.Join("/", source.Destination)),
+		llb.Checksum(digest.NewDigestFromEncoded(digest.SHA256, source.SHA256)),
+		llb.WithCustomNamef(node.Prefix+"download %s -> %s", source.URL, source.Destination),
+	)
+}
+
+// probeHTTP checks whether the source can be downloaded from the primary URL.
+//
+// The download is content-addressed, so the probe populates the cache for the actual build.
+func (node *NodeLLB) probeHTTP(ctx context.Context, source v1alpha2.Source) bool {
+	if node.Graph.probeFn == nil {
+		return true
+	}
+
+	return node.Graph.probeFn(ctx, node.httpSource(source)) == nil
+}
+
+func (node *NodeLLB) downloadHTTP(step v1alpha2.Step, source v1alpha2.Source) llb.State {
+	download := node.httpSource(source)
+
+	checksummer := node.Graph.Checksummer.File(
+		llb.Mkfile("/checksums", 0o644, source.ToSHA512Sum()).
+			Copy(download, "/", "/", defaultCopyOptions(node.Graph.Options, false)).
+			Mkdir(emptyDir, constants.DefaultDirMode, llb.WithParents(true)),
+		llb.WithCustomName(node.Prefix+"cksum-prepare"),
+	).Run(
+		append(
+			node.Graph.commonRunOptions,
+			llb.Shlex("sha512sum -c -w /checksums"),
+			llb.WithCustomName(node.Prefix+"cksum-verify"),
+			llb.Network(pb.NetMode_NONE),
+		)...,
+	).Root()
+
+	return llb.Scratch().File(
+		node.placeSource(step, source, download).
+			Copy(checksummer, emptyDir, "/", defaultCopyOptions(node.Graph.Options, false)), // TODO: this is "fake" dependency on checksummer
+		llb.WithCustomName(node.Prefix+"download finalize"),
+	)
+}
+
+// placeSource copies the downloaded source (rooted at "/" of the download state) into the step temporary directory,
+// extracting the archive if requested.
+func (node *NodeLLB) placeSource(step v1alpha2.Step, source v1alpha2.Source, download llb.State) *llb.FileAction {
+	if !source.Extract {
+		return llb.Copy(download, "/", step.WorkDir, node.stepCopyOptions(step))
+	}
 
-		checksummer := node.Graph.Checksummer.File(
-			llb.Mkfile("/checksums", 0o644, source.ToSHA512Sum()).
-				Copy(download, "/", "/", defaultCopyOptions(node.Graph.Options, false)).
-				Mkdir(emptyDir, constants.DefaultDirMode, llb.WithParents(true)),
-			llb.WithCustomName(node.Prefix+"cksum-prepare"),
-		).Run(
+	extracted := llb.Scratch().File(
+		llb.Copy(download, filepath.Join("/", source.Destination), "/", &llb.CopyInfo{
+			AttemptUnpack:  true,
+			CreateDestPath: true,
+		}),
+		llb.WithCustomNamef(node.Prefix+"extract %s", source.Destination),
+	)
+
+	copyOptions := node.stepCopyOptions(step)
+	src := "/"
+
+	// like `tar --strip-components`, copy contents of the directories N levels deep
+	if source.StripComponents > 0 {
+		src = path.Join("/", strings.Repeat("*/", source.StripComponents))
+		copyOptions.AllowWildcard = true
+	}
+
+	return llb.Copy(extracted, src, filepath.Join(step.WorkDir, source.ExtractTo), copyOptions)
+}
+
+// downloadMirrors downloads the source from the mirrors, it is used only if the primary URL fails.
+func (node *NodeLLB) downloadMirrors(step v1alpha2.Step, source v1alpha2.Source) llb.State {
+	// the exec is keyed by the list of mirrors and checksums,
+	// so the result is cached the same way no matter which mirror served the file
+	download := node.Graph.Fetcher.Run(
+		append(
+			node.Graph.commonRunOptions,
+			llb.Args(append([]string{"/bin/sh", "-c", fetchScript, "fetch", source.Destination}, source.Mirrors...)),
+			llb.AddEnv("SHA256", source.SHA256),
+			llb.AddEnv("SHA512", source.SHA512),
+			llb.WithCustomNamef(node.Prefix+"download mirrors of %s -> %s", source.URL, source.Destination),
+		)...,
+	).AddMount(downloadDir, llb.Scratch())
+
+	return llb.Scratch().File(
+		node.placeSource(step, source, download),
+		llb.WithCustomName(node.Prefix+"download finalize"),
+	)
+}
+
+func (node *NodeLLB) downloadGit(step v1alpha2.Step, source v1alpha2.Source) llb.State {
+	// buildkit verifies that the ref resolves to the pinned commit
+	gitOptions := []llb.GitOption{
+		llb.GitRef(source.Git.GetRef()),
+		llb.GitChecksum(source.Git.Commit),
+		llb.WithCustomNamef(node.Prefix+"git %s@%s -> %s", source.Git.Repository, source.Git.Commit, source.Destination),
+	}
+
+	if !source.Git.Submodules {
+		gitOptions = append(gitOptions, llb.GitSkipSubmodules())
+	}
+
+	if source.Git.KeepGitDir {
+		gitOptions = append(gitOptions, llb.KeepGitDir())
+	}
+
+	checkout := llb.Git(source.Git.Repository, "", gitOptions...)
+
+	return llb.Scratch().File(
+		llb.Copy(checkout, "/", filepath.Join(step.WorkDir, source.Destination), node.stepCopyOptions(step)),
+		llb.WithCustomName(node.Prefix+"git finalize"),
+	)
+}
+
+func (node *NodeLLB) stepPatches(root llb.State, i int, step v1alpha2.Step) llb.State {
+	// patches are applied with busybox `patch`, so the base image doesn't need to have it
+	for _, patch := range step.Patches {
+		dir := filepath.Join(step.WorkDir, patch.Directory)
+
+		patched := node.Graph.Checksummer.Run(
 			append(
 				node.Graph.commonRunOptions,
-				llb.Shlex("sha512sum -c -w /checksums"),
-				llb.WithCustomName(node.Prefix+"cksum-verify"),
+				llb.Args([]string{"patch", "-p" + strconv.Itoa(patch.GetStrip()), "-i", filepath.Join(pkgDir, patch.File)}),
+				llb.Dir(patchDir),
+				llb.AddMount(pkgDir, root, llb.SourcePath(pkgDir), llb.Readonly),
 				llb.Network(pb.NetMode_NONE),
+				llb.WithCustomNamef("%spatch-%d %s", node.Prefix, i, patch.File),
 			)...,
-		).Root()
+		).AddMount(patchDir, root, llb.SourcePath(dir))
 
-		stages = append(
-			stages,
-			llb.Scratch().File(
-				llb.Copy(download, "/", step.TmpDir, defaultCopyOptions(node.Graph.Options, false)).
-					Copy(checksummer, emptyDir, "/", defaultCopyOptions(node.Graph.Options, false)), // TODO: this is "fake" dependency on checksummer
-				llb.WithCustomName(node.Prefix+"download finalize"),
-			),
+		// replace the directory to keep files removed by the patch removed
+		root = root.File(
+			llb.Rm(dir).
+				Copy(patched, "/", dir, node.stepCopyOptions(step)),
+			llb.WithCustomNamef("%spatch-%d %s -> %s", node.Prefix, i, patch.File, dir),
 		)
 	}
 
-	return root.WithOutput(llb.Merge(stages, llb.WithCustomName(node.Prefix+"download")).Output())
+	return root
+}
+
+func (node *NodeLLB) env(root llb.State) llb.State {
+	return addEnvironment(root, node.Pkg.Env)
 }
 
 func (node *NodeLLB) stepEnvironment(root llb.State, step v1alpha2.Step) llb.State {
-	vars := step.Env
+	return addEnvironment(root, step.Env)
+}
+
+func addEnvironment(root llb.State, vars v1alpha2.Environment) llb.State {
 	keys := make([]string, 0, len(vars))
 
 	for key := range vars {
@@ -340,6 +560,11 @@ func (node *NodeLLB) stepScripts(root llb.State, i int, step v1alpha2.Step) llb.
 
 		scriptRoot := root
 
+		shell := node.Pkg.Shell
+		if step.Shell != "" {
+			shell = step.Shell
+		}
+
 		for _, instruction := range script.Instructions {
 			runOptions := append([]llb.RunOption(nil), node.Graph.commonRunOptions...)
 
@@ -351,6 +576,20 @@ func (node *NodeLLB) stepScripts(root llb.State, i int, step v1alpha2.Step) llb.
 			case v1alpha2.NetworkModeDefault: // do nothing
 			}
 
+			runOptions = append(runOptions, xslices.Map(step.Secrets, secretRunOption)...)
+			runOptions = append(runOptions, xslices.Map(step.Mounts, node.mountRunOption)...)
+
+			if step.SSH.Enabled {
+				sshOptions := []llb.SSHOption{llb.SSHID(step.SSH.GetID())}
+
+				if !step.SSH.Required {
+					sshOptions = append(sshOptions, llb.SSHOptional)
+				}
+
+				// buildkit sets SSH_AUTH_SOCK to the mounted socket
+				runOptions = append(runOptions, llb.AddSSHSocket(sshOptions...))
+			}
+
 			runOptions = append(runOptions, xslices.Map(step.CachePaths, func(p string) llb.RunOption {
 				return llb.AddMount(
 					p,
@@ -362,10 +601,14 @@ func (node *NodeLLB) stepScripts(root llb.State, i int, step v1alpha2.Step) llb.
 				)
 			})...)
 
+			if step.User != "" {
+				runOptions = append(runOptions, llb.User(step.User))
+			}
+
 			runOptions = append(
 				runOptions,
 				llb.Args([]string{
-					node.Pkg.Shell.Get(),
+					shell.Get(),
 					"-c",
 					instruction.Script(),
 				}),
@@ -398,6 +641,21 @@ func (node *NodeLLB) stepScripts(root llb.State, i int, step v1alpha2.Step) llb.
 	return root
 }
 
+// secretRunOption exposes the secret to the exec, only the secret ID goes into the LLB definition.
+func secretRunOption(secret v1alpha2.Secret) llb.RunOption {
+	secretOptions := []llb.SecretOption{llb.SecretID(secret.ID)}
+
+	if !secret.Required {
+		secretOptions = append(secretOptions, llb.SecretOptional)
+	}
+
+	if secret.Env != "" {
+		return llb.AddSecretWithDest(secret.ID, nil, append(secretOptions, llb.SecretAsEnvName(secret.Env))...)
+	}
+
+	return llb.AddSecret(secret.GetPath(), secretOptions...)
+}
+
 func (node *NodeLLB) stepSBOM(root llb.State, step v1alpha2.Step) llb.State {
 	if step.SBOM.OutputPath == "" {
 		return root
@@ -424,9 +682,10 @@ func (node *NodeLLB) stepSBOM(root llb.State, step v1alpha2.Step) llb.State {
 	return root
 }
 
-func (node *NodeLLB) step(root llb.State, i int, step v1alpha2.Step) llb.State {
+func (node *NodeLLB) step(ctx context.Context, root llb.State, i int, step v1alpha2.Step) llb.State {
 	root = node.stepTmpDir(root, &step)
-	root = node.stepDownload(root, step)
+	root = node.stepDownload(ctx, root, step)
+	root = node.stepPatches(root, i, step)
 	root = node.stepEnvironment(root, step)
 	root = node.stepScripts(root, i, step)
 	root = node.stepSBOM(root, step)
@@ -434,29 +693,61 @@ func (node *NodeLLB) step(root llb.State, i int, step v1alpha2.Step) llb.State {
 	return root
 }
 
-func (node *NodeLLB) finalize(root llb.State) llb.State {
-	stages := make([]llb.State, 0, len(node.Pkg.Finalize))
+func (node *NodeLLB) finalize(root llb.State, output string) llb.State {
+	finalize, _ := node.Pkg.GetFinalize(output) // output is checked by the solver
+	stages := make([]llb.State, 0, len(finalize))
+
+	prefix := node.Prefix
+	if output != "" {
+		prefix += output + ":"
+	}
+
+	for _, fin := range finalize {
+		if !fin.When.Matches(node.Pkg.Context) {
+			continue
+		}
+
+		copyOptions := defaultCopyOptions(node.Graph.Options, true)
+		copyOptions.IncludePatterns = fin.Include
+		copyOptions.ExcludePatterns = fin.Exclude
+		copyOptions.ChownOpt.User.UID = fin.Owner
+		copyOptions.ChownOpt.Group.UID = fin.Group
+
+		if mode, symbolic := fin.GetMode(); mode != 0 || symbolic != "" {
+			copyOptions.Mode = &llb.ChmodOpt{Mode: mode, ModeStr: symbolic}
+		}
 
-	for _, fin := range node.Pkg.Finalize {
 		stages = append(
 			stages,
 			llb.Scratch().File(
-				llb.Copy(root, fin.From, fin.To, defaultCopyOptions(node.Graph.Options, true)),
-				llb.WithCustomNamef(node.Prefix+"finalize %s -> %s", fin.From, fin.To),
+				llb.Copy(root, fin.From, fin.To, copyOptions),
+				llb.WithCustomNamef(prefix+"finalize %s -> %s", fin.From, fin.To),
 			),
 		)
 	}
 
-	return llb.Merge(stages, llb.WithCustomName(node.Prefix+"finalize"))
+	return llb.Merge(stages, llb.WithCustomName(prefix+"finalize"))
 }
 
-// Build converts PackageNode to buildkit LLB.
-func (node *NodeLLB) Build(ctx context.Context) (llb.State, error) {
+// Build converts PackageNode output to buildkit LLB, empty output is the package itself.
+//
+// All the outputs of the package share the build, and only differ in the finalize instructions.
+func (node *NodeLLB) Build(ctx context.Context, output string) (llb.State, error) {
+	root, err := node.build(ctx)
+	if err != nil {
+		return llb.Scratch(), err
+	}
+
+	return node.finalize(root, output), nil
+}
+
+func (node *NodeLLB) build(ctx context.Context) (llb.State, error) {
 	if state, ok := node.Graph.cache[node.PackageNode]; ok {
 		return state, nil
 	}
 
 	root := node.base()
+	root = node.env(root)
 
 	root, err := node.dependencies(ctx, root)
 	if err != nil {
@@ -466,11 +757,17 @@ func (node *NodeLLB) Build(ctx context.Context) (llb.State, error) {
 	root = node.install(root)
 	root = node.context(root)
 
-	for i, step := range node.Pkg.Steps {
-		root = node.step(root, i, step)
+	if err = node.mounts(ctx); err != nil {
+		return llb.Scratch(), err
 	}
 
-	root = node.finalize(root)
+	for i, step := range node.Pkg.Steps {
+		if !step.When.Matches(node.Pkg.Context) {
+			continue
+		}
+
+		root = node.step(ctx, root, i, step)
+	}
 
 	node.Graph.cache[node.PackageNode] = root
 
@@ -5,10 +5,15 @@
 package environment
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"github.com/moby/buildkit/client/llb"
+	"github.com/siderolabs/gen/xslices"
 
+	"github.com/siderolabs/bldr/internal/pkg/constants"
 	"github.com/siderolabs/bldr/internal/pkg/types"
 )
 
@@ -22,11 +27,85 @@ type Options struct {
 	SourceDateEpoch  time.Time
 	CacheIDNamespace string
 	NoCache          bool
+
+	// Defaults override the default variables (see [Default]), e.g. from the Pkgfile.
+	Defaults types.Variables
+	// Platforms override the built-in platform definitions or define additional platforms, e.g. from the Pkgfile.
+	Platforms map[string]Platform
 }
 
 // GetVariables returns set of variables set for options.
 func (options *Options) GetVariables() types.Variables {
-	return Default().
-		Merge(options.BuildPlatform.BuildVariables()).
-		Merge(options.TargetPlatform.TargetVariables())
+	return options.variablesProvenance().Variables()
+}
+
+func (options *Options) variablesProvenance() *types.Provenance {
+	provenance := types.NewProvenance()
+
+	provenance.Merge("default", Default())
+	provenance.Merge(constants.Pkgfile+" defaults", options.Defaults)
+	provenance.Merge("build platform "+options.BuildPlatform.ID, options.BuildPlatform.BuildVariables())
+	provenance.Merge("target platform "+options.TargetPlatform.ID, options.TargetPlatform.TargetVariables())
+
+	return provenance
+}
+
+// GetPlatform returns the platform definition by ID, taking into account platforms defined in the options.
+func (options *Options) GetPlatform(id string) (Platform, error) {
+	id = NormalizeID(id)
+
+	if platform, ok := options.Platforms[id]; ok {
+		return platform, nil
+	}
+
+	if platform, ok := Platforms[id]; ok {
+		return platform, nil
+	}
+
+	return Platform{}, fmt.Errorf("platform %q is not defined, supported platforms: %q", id, xslices.Map(options.AllPlatforms(), Platform.String))
+}
+
+// AllPlatforms returns all platforms (built-in and defined in the options) sorted by ID.
+func (options *Options) AllPlatforms() []Platform {
+	all := maps.Clone(Platforms)
+	maps.Copy(all, options.Platforms)
+
+	return xslices.Map(slices.Sorted(maps.Keys(all)), func(id string) Platform { return all[id] })
+}
+
+// ResolvePlatforms resolves build and target platforms by ID with the platform definitions from the options.
+//
+// It should be called after changing [Options.Platforms].
+func (options *Options) ResolvePlatforms() error {
+	buildPlatform, err := options.GetPlatform(options.BuildPlatform.ID)
+	if err != nil {
+		return fmt.Errorf("build platform: %w", err)
+	}
+
+	targetPlatform, err := options.GetPlatform(options.TargetPlatform.ID)
+	if err != nil {
+		return fmt.Errorf("target platform: %w", err)
+	}
+
+	options.BuildPlatform, options.TargetPlatform = buildPlatform, targetPlatform
+
+	return nil
+}
+
+// GetContext returns set of variables for templating and conditions.
+//
+// In addition to [Options.GetVariables], it contains build and target platforms,
+// which are not pushed into the build environment.
+func (options *Options) GetContext() types.Variables {
+	return options.GetProvenance().Variables()
+}
+
+// GetProvenance returns the context (see [Options.GetContext]) with the origins of each variable.
+func (options *Options) GetProvenance() *types.Provenance {
+	provenance := options.variablesProvenance()
+
+	provenance.Merge("build platform "+options.BuildPlatform.ID, types.Variables{constants.BuildPlatformVariable: options.BuildPlatform.ID})
+	provenance.Merge("target platform "+options.TargetPlatform.ID, types.Variables{constants.TargetPlatformVariable: options.TargetPlatform.ID})
+
+	return provenance
 }
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package environment_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+func TestOptionsPlatforms(t *testing.T) {
+	custom := environment.LinuxAmd64
+	custom.Target = "x86_64-acme-linux-musl"
+
+	var mips environment.Platform
+
+	require.NoError(t, mips.Set("linux/mips64le"))
+
+	options := environment.Options{
+		BuildPlatform:  environment.LinuxAmd64,
+		TargetPlatform: mips,
+		Defaults:       types.Variables{"VENDOR": "acme"},
+	}
+
+	err := options.ResolvePlatforms()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `target platform: platform "linux/mips64le" is not defined`)
+
+	mips.Arch = "mips64el"
+	mips.Target = "mips64el-acme-linux-musl"
+
+	options.Platforms = map[string]environment.Platform{
+		custom.ID: custom,
+		mips.ID:   mips,
+	}
+
+	require.NoError(t, options.ResolvePlatforms())
+
+	vars := options.GetVariables()
+
+	assert.Equal(t, "acme", vars["VENDOR"])
+	assert.Equal(t, "/talos", vars["SYSROOT"])
+	assert.Equal(t, "x86_64-linux-musl", vars["BUILD"])
+	assert.Equal(t, "mips64el", vars["ARCH"])
+	assert.Equal(t, "mips64el-acme-linux-musl", vars["TARGET"])
+
+	p, err := options.GetPlatform("linux/amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "x86_64-acme-linux-musl", p.Target)
+
+	assert.Len(t, options.AllPlatforms(), len(environment.Platforms)+1)
+
+	provenance := options.GetProvenance()
+
+	assert.Equal(t, options.GetContext(), provenance.Variables())
+	assert.Equal(t, []types.Origin{
+		{Source: "default", Value: "talos", Action: types.OriginSet},
+		{Source: "Pkgfile defaults", Value: "acme", Action: types.OriginSet},
+	}, provenance.Origins("VENDOR"))
+	assert.Equal(t, []types.Origin{
+		{Source: "target platform linux/mips64le", Value: "linux/mips64le", Action: types.OriginSet},
+	}, provenance.Origins("TARGETPLATFORM"))
+}
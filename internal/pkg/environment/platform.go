@@ -6,11 +6,13 @@ package environment
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/containerd/platforms"
 	"github.com/moby/buildkit/client/llb"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
+	"github.com/siderolabs/bldr/internal/pkg/constants"
 	"github.com/siderolabs/bldr/internal/pkg/types"
 )
 
@@ -51,16 +53,50 @@ func (p Platform) String() string {
 }
 
 // Set implements pflag.Value interface.
+//
+// Platform ID is normalized, so that e.g. `linux/arm` resolves to `linux/arm/v7`.
+// Platforms which are not built-in are accepted if the ID is valid, as they might be defined in the Pkgfile:
+// such platforms are resolved with [Options.ResolvePlatforms].
 func (p *Platform) Set(id string) error {
-	if _, exists := Platforms[id]; !exists {
-		return fmt.Errorf("platform %q is not defined", id)
+	spec, err := platforms.Parse(id)
+	if err != nil {
+		return fmt.Errorf("invalid platform %q: %w", id, err)
+	}
+
+	spec = platforms.Normalize(spec)
+
+	if platform, exists := Platforms[platforms.Format(spec)]; exists {
+		*p = platform
+
+		return nil
 	}
 
-	*p = Platforms[id]
+	*p = Platform{
+		ID:           platforms.Format(spec),
+		LLBPlatform:  llb.Platform(spec),
+		PlatformSpec: spec,
+	}
 
 	return nil
 }
 
+// NormalizeID returns the normalized platform ID (e.g. `linux/arm` -> `linux/arm/v7`).
+//
+// Invalid IDs are returned as is.
+func NormalizeID(id string) string {
+	spec, err := platforms.Parse(id)
+	if err != nil {
+		return id
+	}
+
+	return platforms.Format(platforms.Normalize(spec))
+}
+
+// VarsYaml returns the filename of the platform-specific vars.yaml, e.g. `vars.linux-arm64.yaml` for `linux/arm64`.
+func VarsYaml(id string) string {
+	return strings.Replace(constants.VarsPlatformYamlGlob, "*", strings.ReplaceAll(NormalizeID(id), "/", "-"), 1)
+}
+
 // Type implements pflag.Value interface.
 func (p *Platform) Type() string {
 	return "platform"
@@ -89,6 +125,50 @@ var (
 		LLBPlatform:  llb.LinuxArm64,
 		PlatformSpec: platforms.MustParse("linux/arm64"),
 	}
+
+	LinuxArmV7 = Platform{
+		ID:           "linux/arm/v7",
+		Arch:         "armv7",
+		Target:       "armv7-talos-linux-musleabihf",
+		Build:        "armv7-linux-musleabihf",
+		Host:         "armv7-linux-musleabihf",
+		CFlags:       "-O2 -g0 -march=armv7-a -mfpu=vfpv3-d16 -mfloat-abi=hard",
+		LLBPlatform:  llb.LinuxArmhf,
+		PlatformSpec: platforms.MustParse("linux/arm/v7"),
+	}
+
+	LinuxRiscv64 = Platform{
+		ID:           "linux/riscv64",
+		Arch:         "riscv64",
+		Target:       "riscv64-talos-linux-musl",
+		Build:        "riscv64-linux-musl",
+		Host:         "riscv64-linux-musl",
+		CFlags:       "-O2 -g0 -march=rv64gc -mabi=lp64d",
+		LLBPlatform:  llb.LinuxRiscv64,
+		PlatformSpec: platforms.MustParse("linux/riscv64"),
+	}
+
+	LinuxPpc64le = Platform{
+		ID:           "linux/ppc64le",
+		Arch:         "powerpc64le",
+		Target:       "powerpc64le-talos-linux-musl",
+		Build:        "powerpc64le-linux-musl",
+		Host:         "powerpc64le-linux-musl",
+		CFlags:       "-O2 -g0",
+		LLBPlatform:  llb.LinuxPpc64le,
+		PlatformSpec: platforms.MustParse("linux/ppc64le"),
+	}
+
+	LinuxS390x = Platform{
+		ID:           "linux/s390x",
+		Arch:         "s390x",
+		Target:       "s390x-talos-linux-musl",
+		Build:        "s390x-linux-musl",
+		Host:         "s390x-linux-musl",
+		CFlags:       "-O2 -g0",
+		LLBPlatform:  llb.LinuxS390x,
+		PlatformSpec: platforms.MustParse("linux/s390x"),
+	}
 )
 
 // Platforms is mapping of platform ID to Platform.
@@ -98,6 +178,10 @@ func init() {
 	for _, platform := range []Platform{
 		LinuxAmd64,
 		LinuxArm64,
+		LinuxArmV7,
+		LinuxRiscv64,
+		LinuxPpc64le,
+		LinuxS390x,
 	} {
 		Platforms[platform.ID] = platform
 	}
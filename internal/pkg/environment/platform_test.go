@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package environment_test
+
+import (
+	"testing"
+
+	"github.com/containerd/platforms"
+	"github.com/siderolabs/gen/xslices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+)
+
+func TestPlatformSet(t *testing.T) {
+	for _, test := range []struct {
+		id       string
+		expected environment.Platform
+	}{
+		{id: "linux/amd64", expected: environment.LinuxAmd64},
+		{id: "linux/arm64/v8", expected: environment.LinuxArm64},
+		{id: "linux/arm/v7", expected: environment.LinuxArmV7},
+		{id: "linux/arm", expected: environment.LinuxArmV7},
+		{id: "linux/riscv64", expected: environment.LinuxRiscv64},
+	} {
+		t.Run(test.id, func(t *testing.T) {
+			var p environment.Platform
+
+			require.NoError(t, p.Set(test.id))
+			assert.Equal(t, test.expected.ID, p.ID)
+		})
+	}
+
+	// platforms which are not built-in are resolved later with the Pkgfile definitions
+	var p environment.Platform
+
+	require.NoError(t, p.Set("linux/mips64le"))
+	assert.Equal(t, "linux/mips64le", p.ID)
+	assert.Empty(t, p.Target)
+
+	require.Error(t, p.Set("linux/"))
+}
+
+func TestPlatforms(t *testing.T) {
+	all := (&environment.Options{}).AllPlatforms()
+
+	assert.Equal(t, []string{"linux/amd64", "linux/arm/v7", "linux/arm64", "linux/ppc64le", "linux/riscv64", "linux/s390x"}, xslices.Map(all, environment.Platform.String))
+
+	for _, p := range all {
+		// platform ID should match the OCI platform
+		assert.Equal(t, p.ID, platforms.Format(p.PlatformSpec))
+		assert.NotEmpty(t, p.Arch)
+		assert.Contains(t, p.Target, "-talos-linux-")
+		assert.Contains(t, p.Build, "-linux-")
+		assert.Contains(t, p.Host, "-linux-")
+		assert.NotEmpty(t, p.CFlags)
+		assert.NotNil(t, p.LLBPlatform)
+	}
+}
+
+func TestVarsYaml(t *testing.T) {
+	assert.Equal(t, "vars.linux-arm64.yaml", environment.VarsYaml("linux/arm64"))
+	assert.Equal(t, "vars.linux-arm-v7.yaml", environment.VarsYaml("linux/arm"))
+}
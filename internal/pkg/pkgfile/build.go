@@ -8,12 +8,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	ctrplatforms "github.com/containerd/platforms"
+	"github.com/hashicorp/go-multierror"
 	controlapi "github.com/moby/buildkit/api/services/control"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
@@ -26,6 +28,7 @@ import (
 	"github.com/siderolabs/bldr/internal/pkg/convert"
 	"github.com/siderolabs/bldr/internal/pkg/environment"
 	"github.com/siderolabs/bldr/internal/pkg/solver"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
 )
 
 const (
@@ -56,6 +59,9 @@ type platformContextCache struct { //nolint:govet
 	baseOptions environment.Options
 	exportMap   bool
 	c           client.Client
+
+	// pkgfile is parsed once and shared by all platforms
+	pkgfile *v1alpha2.Pkgfile
 }
 
 func newPlatformContextCache(baseOptions environment.Options, exportMap bool, c client.Client) *platformContextCache {
@@ -89,16 +95,31 @@ func (cache *platformContextCache) get(ctx context.Context, platform environment
 		return platformContext{}, fmt.Errorf("error loading packages for %s: %w", platform, err)
 	}
 
+	if cache.pkgfile == nil {
+		cache.pkgfile, err = solver.ReadPkgfile(ctx, pkgRef)
+		if err != nil {
+			return platformContext{}, err
+		}
+	}
+
+	// Pkgfile might override platform definitions and default variables
+	if err = cache.pkgfile.ConfigureOptions(&options); err != nil {
+		return platformContext{}, fmt.Errorf("error configuring platform %s: %w", platform, err)
+	}
+
 	opts := cache.c.BuildOpts().Opts
 
-	buildContext := options.GetVariables().Copy()
+	buildContext := options.GetContext().Copy()
 	// push build arguments as `BUILD_ARGS_` prefixed variables
-	buildContext.Merge(prefix(filter(opts, buildArgPrefix), "BUILD_ARG_"))
+	for key, value := range prefix(filter(opts, buildArgPrefix), "BUILD_ARG_") {
+		buildContext[key] = value
+	}
 
 	loader := solver.BuildkitFrontendLoader{
 		Context: buildContext,
 		Ref:     pkgRef,
 		Ctx:     ctx,
+		Pkgfile: cache.pkgfile,
 	}
 
 	packages, err := solver.NewPackages(&loader)
@@ -140,15 +161,15 @@ func solveTarget(
 		// labeled with the requested target and ARCH/TARGET reflect it, while
 		// BUILD/HOST and LLB exec placement follow the build platform.
 		if buildPlatform := graph.Root.Pkg.BuildPlatform; buildPlatform != "" {
-			p, ok := environment.Platforms[buildPlatform]
-			if !ok {
-				return nil, fmt.Errorf("package %q: buildPlatform %q is not supported", graph.Root.Name, buildPlatform)
+			p, err := options.GetPlatform(buildPlatform)
+			if err != nil {
+				return nil, fmt.Errorf("package %q: buildPlatform: %w", graph.Root.Name, err)
 			}
 
 			options.BuildPlatform = p
 		}
 
-		def, err := convert.MarshalLLB(ctx, graph, solveTarget(platformContextCache, c, cacheImports), &options)
+		def, err := convert.MarshalLLB(ctx, graph, solveTarget(platformContextCache, c, cacheImports), probeState(c), &options)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal LLB for platform %s and target %s: %w", platform, target, err)
 		}
@@ -165,6 +186,22 @@ func solveTarget(
 	}
 }
 
+func probeState(c client.Client) convert.ProbeFunc {
+	return func(ctx context.Context, state llb.State) error {
+		def, err := state.Marshal(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = c.Solve(ctx, client.SolveRequest{
+			Definition: def.ToPB(),
+			Evaluate:   true,
+		})
+
+		return err
+	}
+}
+
 // Build is an entrypoint for buildkit frontend.
 //
 //nolint:gocyclo,cyclop,gocognit
@@ -261,6 +298,24 @@ func Build(ctx context.Context, c client.Client, options *environment.Options) (
 	platformContextCache := newPlatformContextCache(*options, exportMap, c)
 	solveTarget := solveTarget(platformContextCache, c, cacheImports)
 
+	// resolve the target for each platform upfront to report all unsupported platforms before solving anything
+	var resolveErr *multierror.Error
+
+	for _, platform := range platforms {
+		platformContext, err := platformContextCache.get(ctx, platform)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get platform context for %s: %w", platform, err)
+		}
+
+		if _, err = platformContext.packages.Resolve(options.Target); err != nil {
+			resolveErr = multierror.Append(resolveErr, fmt.Errorf("platform %s: %w", platform, err))
+		}
+	}
+
+	if err := resolveErr.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
 	eg, ctx := errgroup.WithContext(ctx)
 
 	for i, platform := range platforms {
@@ -293,11 +348,14 @@ func Build(ctx context.Context, c client.Client, options *environment.Options) (
 				},
 				Config: v1.DockerOCIImageConfig{
 					ImageConfig: specs.ImageConfig{
-						Labels: platformContext.packages.ImageLabels(),
+						Labels: platformContext.packages.ImageLabels(options.Target),
 					},
 				},
 			}
 
+			pkgImage := platformContext.packages.ImageConfig(options.Target)
+			applyImageConfig(&img, pkgImage)
+
 			config, err := json.Marshal(img)
 			if err != nil {
 				return fmt.Errorf("error marshaling image config: %w", err)
@@ -306,10 +364,14 @@ func Build(ctx context.Context, c client.Client, options *environment.Options) (
 			if !exportMap {
 				res.AddMeta(exptypes.ExporterImageConfigKey, config)
 				res.SetRef(ref)
+
+				addAnnotations(res, nil, pkgImage)
 			} else {
 				k := ctrplatforms.Format(platform.PlatformSpec)
 				res.AddMeta(fmt.Sprintf("%s/%s", exptypes.ExporterImageConfigKey, k), config)
 				res.AddRef(k, ref)
+
+				addAnnotations(res, &platform.PlatformSpec, pkgImage)
 				expPlatforms.Platforms[i] = exptypes.Platform{
 					ID:       k,
 					Platform: platform.PlatformSpec,
@@ -336,8 +398,54 @@ func Build(ctx context.Context, c client.Client, options *environment.Options) (
 	return res, nil
 }
 
+// applyImageConfig merges the image configuration of the target package into the image config.
+func applyImageConfig(img *v1.DockerOCIImage, pkgImage *v1alpha2.Image) {
+	if pkgImage == nil {
+		return
+	}
+
+	config := &img.Config
+
+	config.Entrypoint = pkgImage.Entrypoint
+	config.Cmd = pkgImage.Cmd
+	config.Env = pkgImage.GetEnv()
+	config.WorkingDir = pkgImage.WorkDir
+	config.User = pkgImage.User
+
+	if len(pkgImage.ExposedPorts) > 0 {
+		config.ExposedPorts = make(map[string]struct{}, len(pkgImage.ExposedPorts))
+
+		for _, port := range pkgImage.GetExposedPorts() {
+			config.ExposedPorts[port] = struct{}{}
+		}
+	}
+
+	if len(pkgImage.Labels) > 0 {
+		labels := maps.Clone(config.Labels)
+		if labels == nil {
+			labels = make(map[string]string, len(pkgImage.Labels))
+		}
+
+		// package labels take precedence over Pkgfile labels
+		maps.Copy(labels, pkgImage.Labels)
+
+		config.Labels = labels
+	}
+}
+
+// addAnnotations adds OCI annotations of the target package to the image manifest.
+func addAnnotations(res *client.Result, platform *specs.Platform, pkgImage *v1alpha2.Image) {
+	if pkgImage == nil {
+		return
+	}
+
+	for key, value := range pkgImage.Annotations {
+		res.AddMeta(exptypes.AnnotationManifestKey(platform, key), []byte(value))
+	}
+}
+
 func fetchPkgs(ctx context.Context, c client.Client) (client.Reference, error) {
-	name := fmt.Sprintf("load %s, %ss and %ss", constants.Pkgfile, constants.PkgYaml, constants.VarsYaml)
+	name := fmt.Sprintf("load %s, %ss, %ss and patches", constants.Pkgfile, constants.PkgYaml, constants.VarsYaml)
 
 	src := llb.Local(
 		localNameDockerfile,
@@ -345,7 +453,11 @@ func fetchPkgs(ctx context.Context, c client.Client) (client.Reference, error) {
 			constants.Pkgfile,
 			"**/" + constants.PkgYaml,
 			"**/" + constants.VarsYaml,
+			// platforms might be defined in the Pkgfile, which is not loaded yet
+			"**/" + constants.VarsPlatformYamlGlob,
 			"**/*" + constants.TemplateExt,
+			"**/*" + constants.PatchExt,
+			"**/*" + constants.DiffExt,
 			"*/",
 		}),
 		llb.ExcludePatterns([]string{
@@ -6,7 +6,9 @@
 package sbom
 
 import (
+	"cmp"
 	"fmt"
+	"path"
 	"time"
 
 	"github.com/anchore/syft/syft/artifact"
@@ -38,20 +40,37 @@ func parseCPEs(cpeStrings []string) ([]cpe.CPE, error) {
 }
 
 func addPkgSources(sbomDoc *sbom.SBOM, bldrPkg *v1alpha2.Pkg, syftPkg pkg.Package) {
-	// TODO: also add sources from the package itself, like the Pkgfile and patches
+	// TODO: also add sources from the package itself, like the Pkgfile
 	for _, step := range bldrPkg.Steps {
+		if !step.When.Matches(bldrPkg.Context) {
+			continue
+		}
+
 		for _, source := range step.Sources {
-			fileCoordinates := file.NewCoordinates(source.URL, "bldr sources")
-
-			sbomDoc.Artifacts.FileDigests[fileCoordinates] = []file.Digest{
-				{
-					Algorithm: "sha256",
-					Value:     source.SHA256,
-				},
-				{
-					Algorithm: "sha512",
-					Value:     source.SHA512,
-				},
+			var fileCoordinates file.Coordinates
+
+			if source.IsGit() {
+				fileCoordinates = file.NewCoordinates(source.Git.Repository+"@"+source.Git.Commit, "bldr sources")
+
+				sbomDoc.Artifacts.FileDigests[fileCoordinates] = []file.Digest{
+					{
+						Algorithm: source.Git.DigestAlgorithm(),
+						Value:     source.Git.Commit,
+					},
+				}
+			} else {
+				fileCoordinates = file.NewCoordinates(source.URL, "bldr sources")
+
+				sbomDoc.Artifacts.FileDigests[fileCoordinates] = []file.Digest{
+					{
+						Algorithm: "sha256",
+						Value:     source.SHA256,
+					},
+					{
+						Algorithm: "sha512",
+						Value:     source.SHA512,
+					},
+				}
 			}
 
 			// Make sure the file is linked to the package
@@ -64,6 +83,41 @@ func addPkgSources(sbomDoc *sbom.SBOM, bldrPkg *v1alpha2.Pkg, syftPkg pkg.Packag
 	}
 }
 
+func addPkgPatches(sbomDoc *sbom.SBOM, bldrPkg *v1alpha2.Pkg, syftPkg pkg.Package) {
+	applied := map[string]struct{}{}
+
+	for _, step := range bldrPkg.Steps {
+		if !step.When.Matches(bldrPkg.Context) {
+			continue
+		}
+
+		for _, patch := range step.Patches {
+			applied[patch.File] = struct{}{}
+		}
+	}
+
+	for _, patch := range bldrPkg.GetPatchFiles() {
+		if _, ok := applied[patch.Path]; !ok {
+			continue
+		}
+
+		fileCoordinates := file.NewCoordinates(path.Join("/", bldrPkg.BaseDir, patch.Path), "bldr patches")
+
+		sbomDoc.Artifacts.FileDigests[fileCoordinates] = []file.Digest{
+			{
+				Algorithm: "sha256",
+				Value:     patch.SHA256,
+			},
+		}
+
+		sbomDoc.Relationships = append(sbomDoc.Relationships, artifact.Relationship{
+			From: syftPkg,
+			To:   fileCoordinates,
+			Type: artifact.ContainsRelationship,
+		})
+	}
+}
+
 // CreatePackageSBOM populates an SBOM document with data from the provided package.
 func CreatePackageSBOM(bldrPkg *v1alpha2.Pkg, sbomMetadata v1alpha2.SBOMStep) (*sbom.SBOM, error) {
 	cpes, err := parseCPEs(sbomMetadata.CPEs)
@@ -71,9 +125,13 @@ func CreatePackageSBOM(bldrPkg *v1alpha2.Pkg, sbomMetadata v1alpha2.SBOMStep) (*
 		return nil, err
 	}
 
-	name := bldrPkg.Name
-	if sbomMetadata.Name != "" {
-		name = sbomMetadata.Name
+	// package metadata is used as defaults for the SBOM
+	name := cmp.Or(sbomMetadata.Name, bldrPkg.Name)
+	pkgVersion := cmp.Or(sbomMetadata.Version, bldrPkg.Version)
+
+	licenses := sbomMetadata.Licenses
+	if len(licenses) == 0 && bldrPkg.License != "" {
+		licenses = []string{bldrPkg.License}
 	}
 
 	sbomDoc := &sbom.SBOM{
@@ -81,7 +139,7 @@ func CreatePackageSBOM(bldrPkg *v1alpha2.Pkg, sbomMetadata v1alpha2.SBOMStep) (*
 			ID:       "sidero-pkgs",
 			Metadata: source.DirectoryMetadata{},
 			Name:     "sidero-pkgs-" + name,
-			Version:  sbomMetadata.Version,
+			Version:  pkgVersion,
 		},
 		Descriptor: sbom.Descriptor{
 			Name:    "bldr",
@@ -96,7 +154,7 @@ func CreatePackageSBOM(bldrPkg *v1alpha2.Pkg, sbomMetadata v1alpha2.SBOMStep) (*
 
 	syftPkg := pkg.Package{
 		Name:    name,
-		Version: sbomMetadata.Version,
+		Version: pkgVersion,
 		PURL:    sbomMetadata.PURL,
 		Type:    pkg.Type("bldr-package"),
 		FoundBy: "bldr",
@@ -104,12 +162,13 @@ func CreatePackageSBOM(bldrPkg *v1alpha2.Pkg, sbomMetadata v1alpha2.SBOMStep) (*
 			file.NewLocation("/Pkgfile"),
 		),
 		CPEs:     cpes,
-		Licenses: pkg.NewLicenseSet(pkg.NewLicensesFromValues(sbomMetadata.Licenses...)...),
+		Licenses: pkg.NewLicenseSet(pkg.NewLicensesFromValues(licenses...)...),
 	}
 
 	sbomDoc.Artifacts.Packages.Add(syftPkg)
 
 	addPkgSources(sbomDoc, bldrPkg, syftPkg)
+	addPkgPatches(sbomDoc, bldrPkg, syftPkg)
 
 	return sbomDoc, nil
 }
@@ -27,8 +27,10 @@ type BuildkitFrontendLoader struct {
 	Ref     client.Reference
 	//nolint:containedctx
 	Ctx context.Context
+	// Pkgfile (optional) is the already parsed Pkgfile, if not set, it's loaded from the Ref.
+	Pkgfile *v1alpha2.Pkgfile
 
-	pathContexts map[string]types.Variables
+	pathContexts map[string][]types.Variables
 	pkgFile      *v1alpha2.Pkgfile
 }
 
@@ -43,21 +45,23 @@ func (bkfl *BuildkitFrontendLoader) walk(path string, processVars, processPkgs,
 		return fmt.Errorf("error readdir %q: %w", path, err)
 	}
 
-	// 1. find and load variables
-	for _, entry := range entries {
-		if entry.GetPath() == constants.VarsYaml {
-			var contents []byte
-
-			contents, err = bkfl.Ref.ReadFile(bkfl.Ctx, client.ReadRequest{
-				Filename: filepath.Join(path, entry.GetPath()),
-			})
-			if err != nil {
-				return fmt.Errorf("error reading %q under %q: %w", entry.GetPath(), path, err)
-			}
-
-			err = processVars(path, entry.GetPath(), contents)
-			if err != nil {
-				return err
+	// 1. find and load variables, platform vars.yaml is loaded after the vars.yaml
+	for _, varsYaml := range []string{constants.VarsYaml, platformVarsYaml(bkfl.Context)} {
+		for _, entry := range entries {
+			if varsYaml != "" && entry.GetPath() == varsYaml {
+				var contents []byte
+
+				contents, err = bkfl.Ref.ReadFile(bkfl.Ctx, client.ReadRequest{
+					Filename: filepath.Join(path, entry.GetPath()),
+				})
+				if err != nil {
+					return fmt.Errorf("error reading %q under %q: %w", entry.GetPath(), path, err)
+				}
+
+				err = processVars(path, entry.GetPath(), contents)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -115,18 +119,8 @@ func (bkfl *BuildkitFrontendLoader) walk(path string, processVars, processPkgs,
 func (bkfl *BuildkitFrontendLoader) resolveContext(basePath string) types.Variables {
 	context := bkfl.Context.Copy()
 
-	dirs := strings.Split(basePath, string(filepath.Separator))
-
-	for i := 0; i <= len(dirs); i++ {
-		var subPath string
-
-		if i == 0 {
-			subPath = "/"
-		} else {
-			subPath = strings.Join(dirs[:i], string(filepath.Separator))
-		}
-
-		if subcontext, ok := bkfl.pathContexts[subPath]; ok {
+	for _, subPath := range subPaths(basePath, "/") {
+		for _, subcontext := range bkfl.pathContexts[subPath] {
 			context.Merge(subcontext)
 		}
 	}
@@ -134,56 +128,94 @@ func (bkfl *BuildkitFrontendLoader) resolveContext(basePath string) types.Variab
 	return context
 }
 
-func (bkfl *BuildkitFrontendLoader) loadVariables(baseDir, _ string, contents []byte) error {
+func (bkfl *BuildkitFrontendLoader) loadVariables(baseDir, filename string, contents []byte) error {
 	baseContext := bkfl.resolveContext(baseDir)
 
 	var vars types.Variables
 
 	if err := vars.LoadContents(contents, baseContext); err != nil {
-		return fmt.Errorf("error loading variables at %q: %w", baseDir, err)
+		return fmt.Errorf("error loading variables %q: %w", filepath.Join(baseDir, filename), err)
 	}
 
 	log.Printf("loaded variables from %q", baseDir)
 
-	bkfl.pathContexts[baseDir] = vars
+	bkfl.pathContexts[baseDir] = append(bkfl.pathContexts[baseDir], vars)
 
 	return nil
 }
 
-// Load implements PackageLoader.
-func (bkfl *BuildkitFrontendLoader) Load() (*LoadResult, error) {
-	if bkfl.Logger == nil {
-		bkfl.Logger = log.New(log.Writer(), "[loader] ", log.Flags())
-	}
-
-	bkfl.pathContexts = make(map[string]types.Variables)
-
-	contents, err := bkfl.Ref.ReadFile(bkfl.Ctx, client.ReadRequest{
+// ReadPkgfile reads and parses the Pkgfile from buildkit client.Reference.
+func ReadPkgfile(ctx context.Context, ref client.Reference) (*v1alpha2.Pkgfile, error) {
+	contents, err := ref.ReadFile(ctx, client.ReadRequest{
 		Filename: constants.Pkgfile,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("error loading %q: %w", constants.Pkgfile, err)
 	}
 
-	bkfl.pkgFile, err = v1alpha2.NewPkgfile(contents)
+	pkgfile, err := v1alpha2.NewPkgfile(contents)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing %q: %w", constants.Pkgfile, err)
 	}
 
-	log.Printf("loaded %q", constants.Pkgfile)
+	return pkgfile, nil
+}
+
+// Load implements PackageLoader.
+func (bkfl *BuildkitFrontendLoader) Load() (*LoadResult, error) {
+	if bkfl.Logger == nil {
+		bkfl.Logger = log.New(log.Writer(), "[loader] ", log.Flags())
+	}
+
+	bkfl.pathContexts = make(map[string][]types.Variables)
+
+	var err error
+
+	bkfl.pkgFile = bkfl.Pkgfile
+
+	if bkfl.pkgFile == nil {
+		bkfl.pkgFile, err = ReadPkgfile(bkfl.Ctx, bkfl.Ref)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("loaded %q", constants.Pkgfile)
+	}
 
 	bkfl.Context.Merge(bkfl.pkgFile.Vars)
+	bkfl.pkgFile.Variables.ApplyDefaults(bkfl.Context)
 
 	var (
 		pkgs     []*v1alpha2.Pkg
 		multiErr *multierror.Error
 	)
 
-	processPackage := func(baseDir, _ string, contents []byte) error {
-		pkg, err2 := v1alpha2.NewPkg(baseDir, "", contents, bkfl.resolveContext(baseDir))
+	// errors are collected for every file, so that all of them are reported at once
+	processVars := func(baseDir, filename string, contents []byte) error {
+		if err2 := bkfl.loadVariables(baseDir, filename, contents); err2 != nil {
+			log.Print(err2)
+			multiErr = multierror.Append(multiErr, err2)
+		}
+
+		return nil
+	}
+
+	processPackage := func(baseDir, filename string, contents []byte) error {
+		path := filepath.Join(baseDir, filename)
+		context := bkfl.resolveContext(baseDir)
+
+		err2 := bkfl.pkgFile.CheckVariables(context)
 		if err2 != nil {
-			log.Printf("error loading %q: %s", baseDir, err2)
-			multiErr = multierror.Append(multiErr, fmt.Errorf("error loading %q: %w", baseDir, err2))
+			log.Printf("error loading %q: %s", path, err2)
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error loading %q: %w", path, err2))
+
+			return nil
+		}
+
+		pkg, err2 := v1alpha2.ParsePkg(baseDir, "", contents, context)
+		if err2 != nil {
+			log.Printf("error loading %q: %s", path, err2)
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error loading %q: %w", path, err2))
 
 			return nil
 		}
@@ -208,10 +240,32 @@ func (bkfl *BuildkitFrontendLoader) Load() (*LoadResult, error) {
 			return fmt.Errorf("error resolving relative path for templated file %q: %w", filename, err2)
 		}
 
-		return pkg.AttachTemplatedFile(filepath.Join(basePath, filename), contents)
+		if err2 = pkg.AttachTemplatedFile(filepath.Join(basePath, filename), contents); err2 != nil {
+			log.Printf("error attaching template %q: %s", filepath.Join(baseDir, filename), err2)
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error attaching template %q: %w", filepath.Join(baseDir, filename), err2))
+		}
+
+		return nil
+	}
+
+	err = bkfl.walk("/", processVars, processPackage, processTemplatedFile)
+
+	pkgs, extendsErr := v1alpha2.ResolveExtends(pkgs)
+	if extendsErr != nil {
+		log.Printf("error resolving packages: %s", extendsErr)
+		multiErr = multierror.Append(multiErr, extendsErr)
 	}
 
-	err = bkfl.walk("/", bkfl.loadVariables, processPackage, processTemplatedFile)
+	for _, pkg := range pkgs {
+		if err2 := attachPatches(pkg, func(path string) ([]byte, error) {
+			return bkfl.Ref.ReadFile(bkfl.Ctx, client.ReadRequest{
+				Filename: path,
+			})
+		}); err2 != nil {
+			log.Printf("error attaching patches to %q: %s", pkg.Name, err2)
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error attaching patches to %q: %w", pkg.Name, err2))
+		}
+	}
 
 	return &LoadResult{
 		Pkgfile: bkfl.pkgFile,
@@ -24,11 +24,16 @@ import (
 type FilesystemPackageLoader struct {
 	*log.Logger
 	Context types.Variables
+	// Provenance (optional) records the origins of the variables, see [FilesystemPackageLoader.ContextProvenance].
+	//
+	// If set, Context is ignored and taken from the Provenance.
+	Provenance *types.Provenance
 
 	HookOnLoad      func(path string, contents []byte)
 	HookOnVariables func(path string, vars types.Variables)
 
-	pathContexts      map[string]types.Variables
+	provenance        *types.Provenance
+	pathContexts      map[string][]pathContext
 	multiErr          *multierror.Error
 	pkgFile           *v1alpha2.Pkgfile
 	Root              string
@@ -40,6 +45,8 @@ type FilesystemPackageLoader struct {
 }
 
 func (fspl *FilesystemPackageLoader) walkFunc() filepath.WalkFunc {
+	platformVars := platformVarsYaml(fspl.Context)
+
 	return func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			fspl.Printf("error walking %q: %s", path, err)
@@ -58,7 +65,7 @@ func (fspl *FilesystemPackageLoader) walkFunc() filepath.WalkFunc {
 		switch {
 		case info.Name() == constants.PkgYaml:
 			fspl.pkgFilePaths = append(fspl.pkgFilePaths, path)
-		case info.Name() == constants.VarsYaml:
+		case info.Name() == constants.VarsYaml, platformVars != "" && info.Name() == platformVars:
 			fspl.varFilePaths = append(fspl.varFilePaths, path)
 		case strings.HasSuffix(info.Name(), constants.TemplateExt):
 			fspl.templateFilePaths = append(fspl.templateFilePaths, path)
@@ -78,7 +85,12 @@ func (fspl *FilesystemPackageLoader) Load() (*LoadResult, error) {
 		fspl.Root = "."
 	}
 
-	fspl.pathContexts = make(map[string]types.Variables)
+	fspl.pathContexts = make(map[string][]pathContext)
+
+	if fspl.Provenance != nil {
+		fspl.provenance = fspl.Provenance.Copy()
+		fspl.Context = fspl.provenance.Variables().Copy()
+	}
 
 	var err error
 
@@ -96,25 +108,30 @@ func (fspl *FilesystemPackageLoader) Load() (*LoadResult, error) {
 	err = filepath.Walk(fspl.Root, fspl.walkFunc())
 	if err == nil {
 		sort.Slice(fspl.varFilePaths, func(i, j int) bool {
-			return filepath.Dir(fspl.varFilePaths[i]) < filepath.Dir(fspl.varFilePaths[j])
+			if dirI, dirJ := filepath.Dir(fspl.varFilePaths[i]), filepath.Dir(fspl.varFilePaths[j]); dirI != dirJ {
+				return dirI < dirJ
+			}
+
+			// platform vars.yaml is loaded after the vars.yaml at the same level
+			return filepath.Base(fspl.varFilePaths[i]) == constants.VarsYaml && filepath.Base(fspl.varFilePaths[j]) != constants.VarsYaml
 		})
 
 		for _, path := range fspl.varFilePaths {
-			if err = fspl.loadVariables(path); err != nil {
-				fspl.Printf("error loading variables %q: %s", path, err)
-				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error loading variables %q: %w", path, err))
+			if varsErr := fspl.loadVariables(path); varsErr != nil {
+				fspl.Printf("error loading variables %q: %s", path, varsErr)
+				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error loading variables %q: %w", path, varsErr))
+
+				continue
 			}
 
 			fspl.Printf("loaded variables from %q", path)
 		}
 
 		for _, path := range fspl.pkgFilePaths {
-			var pkg *v1alpha2.Pkg
-
-			pkg, err = fspl.loadPkg(path)
-			if err != nil {
-				fspl.Printf("error loading %q: %s", path, err)
-				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error loading %q: %w", path, err))
+			pkg, pkgErr := fspl.loadPkg(path)
+			if pkgErr != nil {
+				fspl.Printf("error loading %q: %s", path, pkgErr)
+				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error loading %q: %w", path, pkgErr))
 
 				continue
 			}
@@ -124,15 +141,30 @@ func (fspl *FilesystemPackageLoader) Load() (*LoadResult, error) {
 		}
 
 		for _, path := range fspl.templateFilePaths {
-			var pkg *v1alpha2.Pkg
-
-			if pkg, err = fspl.attachTemplate(path); err != nil {
-				fspl.Printf("error attaching template %q: %s", path, err)
-				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error attaching template %q: %w", path, err))
+			if pkg, templateErr := fspl.attachTemplate(path); templateErr != nil {
+				fspl.Printf("error attaching template %q: %s", path, templateErr)
+				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error attaching template %q: %w", path, templateErr))
 			} else {
 				fspl.Printf("attached template %q to %q", path, pkg.Name)
 			}
 		}
+
+		var extendsErr error
+
+		fspl.pkgs, extendsErr = v1alpha2.ResolveExtends(fspl.pkgs)
+		if extendsErr != nil {
+			fspl.Printf("error resolving packages: %s", extendsErr)
+			fspl.multiErr = multierror.Append(fspl.multiErr, extendsErr)
+		}
+
+		for _, pkg := range fspl.pkgs {
+			if patchErr := attachPatches(pkg, func(path string) ([]byte, error) {
+				return os.ReadFile(filepath.Join(fspl.Root, path))
+			}); patchErr != nil {
+				fspl.Printf("error attaching patches to %q: %s", pkg.Name, patchErr)
+				fspl.multiErr = multierror.Append(fspl.multiErr, fmt.Errorf("error attaching patches to %q: %w", pkg.Name, patchErr))
+			}
+		}
 	}
 
 	return &LoadResult{
@@ -141,26 +173,53 @@ func (fspl *FilesystemPackageLoader) Load() (*LoadResult, error) {
 	}, multierror.Append(fspl.multiErr, err).ErrorOrNil()
 }
 
+// pathContext is a set of variables loaded from the vars.yaml.
+type pathContext struct {
+	vars types.Variables
+	path string
+}
+
 func (fspl *FilesystemPackageLoader) resolveContext(basePath string) types.Variables {
 	context := fspl.Context.Copy()
 
-	dirs := strings.Split(basePath, string(filepath.Separator))
+	fspl.walkContexts(basePath, func(_ string, vars types.Variables) {
+		context.Merge(vars)
+	})
 
-	for i := 0; i <= len(dirs); i++ {
-		var subPath string
+	return context
+}
 
-		if i == 0 {
-			subPath = "."
-		} else {
-			subPath = strings.Join(dirs[:i], string(filepath.Separator))
+// walkContexts calls merge for each set of variables which applies to the basePath in the order of merging.
+func (fspl *FilesystemPackageLoader) walkContexts(basePath string, merge func(path string, vars types.Variables)) {
+	for _, subPath := range subPaths(basePath, ".") {
+		for _, subcontext := range fspl.pathContexts[subPath] {
+			merge(subcontext.path, subcontext.vars)
 		}
+	}
+}
 
-		if subcontext, ok := fspl.pathContexts[subPath]; ok {
-			context.Merge(subcontext)
-		}
+// ContextProvenance returns the origins of the variables of the packages in the directory (relative to the Root).
+//
+// It returns nil if the Provenance is not set.
+func (fspl *FilesystemPackageLoader) ContextProvenance(dir string) *types.Provenance {
+	if fspl.provenance == nil {
+		return nil
 	}
 
-	return context
+	provenance := fspl.provenance.Copy()
+
+	fspl.walkContexts(dir, provenance.Merge)
+
+	return provenance
+}
+
+// mergeContext merges the variables into the Context (and the Provenance).
+func (fspl *FilesystemPackageLoader) mergeContext(source string, vars types.Variables) {
+	fspl.Context.Merge(vars)
+
+	if fspl.provenance != nil {
+		fspl.provenance.Merge(source, vars)
+	}
 }
 
 func (fspl *FilesystemPackageLoader) loadVariables(path string) error {
@@ -197,7 +256,7 @@ func (fspl *FilesystemPackageLoader) loadVariables(path string) error {
 		fspl.HookOnVariables(basePath, vars)
 	}
 
-	fspl.pathContexts[filepath.Dir(basePath)] = vars
+	fspl.pathContexts[filepath.Dir(basePath)] = append(fspl.pathContexts[filepath.Dir(basePath)], pathContext{path: basePath, vars: vars})
 
 	return nil
 }
@@ -215,6 +274,10 @@ func (fspl *FilesystemPackageLoader) loadPkg(path string) (*v1alpha2.Pkg, error)
 
 	context := fspl.resolveContext(filepath.Dir(basePath))
 
+	if err = fspl.pkgFile.CheckVariables(context); err != nil {
+		return nil, err
+	}
+
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -227,7 +290,7 @@ func (fspl *FilesystemPackageLoader) loadPkg(path string) (*v1alpha2.Pkg, error)
 		return nil, err
 	}
 
-	return v1alpha2.NewPkg(filepath.Dir(basePath), path, contents, context)
+	return v1alpha2.ParsePkg(filepath.Dir(basePath), path, contents, context)
 }
 
 func (fspl *FilesystemPackageLoader) attachTemplate(path string) (*v1alpha2.Pkg, error) {
@@ -306,7 +369,8 @@ func (fspl *FilesystemPackageLoader) loadPkgfile() error {
 		fspl.HookOnVariables(constants.Pkgfile, fspl.pkgFile.Vars)
 	}
 
-	fspl.Context.Merge(fspl.pkgFile.Vars)
+	fspl.mergeContext(constants.Pkgfile, fspl.pkgFile.Vars)
+	fspl.mergeContext(constants.Pkgfile+" variable default", fspl.pkgFile.Variables.Defaults(fspl.Context))
 	fspl.Printf("loaded %q", constants.Pkgfile)
 
 	return nil
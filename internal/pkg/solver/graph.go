@@ -6,6 +6,7 @@ package solver
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/emicklei/dot"
@@ -22,14 +23,35 @@ type PackageDependency struct {
 
 // ID returns unique string for dependency.
 func (dep PackageDependency) ID() string {
-	return fmt.Sprintf("%s-%s-%s", dep.Image, dep.Stage, dep.To)
+	return dep.Dependency.ID()
+}
+
+// label returns graph edge label: package output and the condition.
+func (dep PackageDependency) label() string {
+	return strings.TrimSpace(dep.Output() + " " + dep.When.String())
 }
 
 // PackageNode is a Pkg with associated dependencies.
 type PackageNode struct {
 	Pkg          *v1alpha2.Pkg
 	Name         string
+	Variant      v1alpha2.VariantDefinition
 	Dependencies []PackageDependency
+	// Mounts are dependencies bind mounted into the steps (not copied into the build).
+	Mounts []PackageDependency
+}
+
+// MountDependency returns resolved dependency for the step bind mount.
+func (node *PackageNode) MountDependency(mount v1alpha2.Mount) (PackageDependency, bool) {
+	dep := mount.Dependency()
+
+	for _, mountDep := range node.Mounts {
+		if mountDep.Image == dep.Image && mountDep.Stage == dep.Stage && mountDep.Platform == dep.Platform {
+			return mountDep, true
+		}
+	}
+
+	return PackageDependency{}, false
 }
 
 // DumpDot dumps node and dependencies.
@@ -40,7 +62,7 @@ func (node *PackageNode) DumpDot(g *dot.Graph) dot.Node {
 		var depNode dot.Node
 
 		if dep.IsInternal() {
-			depNode = g.Node(dep.Stage)
+			depNode = g.Node(dep.StageName())
 		} else {
 			imageRef := dep.Image
 			// cut the digest
@@ -54,23 +76,52 @@ func (node *PackageNode) DumpDot(g *dot.Graph) dot.Node {
 
 		edge := depNode.Edge(n)
 
+		if label := dep.label(); label != "" {
+			edge.Attr("label", label)
+		}
+
 		if dep.Runtime {
 			edge.Attr("style", "bold")
 			edge.Attr("color", "forestgreen")
 		}
 	}
 
-	if node.Pkg.Variant == v1alpha2.Alpine {
-		packageNode := g.Node("alpine")
+	for _, dep := range node.Mounts {
+		var depNode dot.Node
+
+		if dep.IsInternal() {
+			depNode = g.Node(dep.StageName())
+		} else {
+			imageRef, _, _ := strings.Cut(dep.Image, "@")
+
+			depNode = g.Node(imageRef)
+			depNode.Box()
+			depNode.Attr("fillcolor", "lemonchiffon")
+			depNode.Attr("style", "filled")
+		}
+
+		edge := depNode.Edge(n).Attr("style", "dashed")
+
+		if label := dep.label(); label != "" {
+			edge.Attr("label", label)
+		}
+	}
+
+	if node.Variant.Image != "" {
+		imageRef, _, _ := strings.Cut(node.Variant.Image, "@")
+
+		// variant might have the same name as the package
+		packageNode := g.Node("variant:" + node.Pkg.Variant.String())
 		packageNode.Box()
+		packageNode.Attr("label", fmt.Sprintf("%s (%s)", node.Pkg.Variant, imageRef))
 		packageNode.Attr("fillcolor", "aquamarine")
 		packageNode.Attr("style", "filled")
 
 		packageNode.Edge(n)
 	}
 
-	for _, dep := range node.Pkg.Install {
-		packageNode := g.Node("Alpine: " + dep)
+	for _, dep := range node.Pkg.Install.Packages {
+		packageNode := g.Node(fmt.Sprintf("%s: %s", node.Pkg.Variant, dep))
 		packageNode.Box()
 		packageNode.Attr("fillcolor", "aquamarine")
 		packageNode.Attr("style", "filled")
@@ -100,6 +151,10 @@ func (node *PackageNode) RuntimeDependencies() (deps []PackageDependency) {
 // PackageGraph capture root of the DAG.
 type PackageGraph struct {
 	Root *PackageNode
+	// Output of the root package, empty for the default output.
+	Output string
+	// FetcherImage downloads sources from the mirrors, it is the image of the `alpine` variant.
+	FetcherImage string
 }
 
 func (graph *PackageGraph) flatten(set PackageSet, node *PackageNode, skip map[*PackageNode]struct{}) PackageSet {
@@ -110,7 +165,7 @@ func (graph *PackageGraph) flatten(set PackageSet, node *PackageNode, skip map[*
 	set = append(set, node)
 	skip[node] = struct{}{}
 
-	for _, dep := range node.Dependencies {
+	for _, dep := range slices.Concat(node.Dependencies, node.Mounts) {
 		if dep.Node != nil {
 			set = graph.flatten(set, dep.Node, skip)
 		}
@@ -5,6 +5,13 @@
 package solver
 
 import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+	"github.com/siderolabs/bldr/internal/pkg/types"
 	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
 )
 
@@ -18,3 +25,34 @@ type LoadResult struct {
 type PackageLoader interface {
 	Load() (*LoadResult, error)
 }
+
+// platformVarsYaml returns the filename of the vars.yaml for the target platform of the context,
+// e.g. `vars.linux-arm64.yaml` for `linux/arm64`.
+//
+// Platform vars.yaml is merged on top of the vars.yaml at the same directory level.
+func platformVarsYaml(context types.Variables) string {
+	platform := context.GetString(constants.TargetPlatformVariable)
+	if platform == "" {
+		return ""
+	}
+
+	return environment.VarsYaml(platform)
+}
+
+// subPaths returns the root and each parent directory of the path (including the path itself) from the top down,
+// e.g. `.`, `a`, `a/b` for `a/b`.
+func subPaths(path, root string) []string {
+	result := []string{root}
+
+	dirs := strings.Split(path, string(filepath.Separator))
+
+	for i := 1; i <= len(dirs); i++ {
+		subPath := strings.Join(dirs[:i], string(filepath.Separator))
+
+		if subPath != "" && !slices.Contains(result, subPath) {
+			result = append(result, subPath)
+		}
+	}
+
+	return result
+}
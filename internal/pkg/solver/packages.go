@@ -5,9 +5,12 @@
 package solver
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
 	"slices"
 
+	"github.com/siderolabs/bldr/internal/pkg/constants"
 	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
 )
 
@@ -15,6 +18,7 @@ import (
 type Packages struct {
 	packages map[string]*v1alpha2.Pkg
 	pkgfile  *v1alpha2.Pkgfile
+	variants map[v1alpha2.Variant]v1alpha2.VariantDefinition
 }
 
 // NewPackages builds Packages using PackageLoader.
@@ -27,6 +31,7 @@ func NewPackages(loader PackageLoader) (*Packages, error) {
 	result := &Packages{
 		packages: make(map[string]*v1alpha2.Pkg, len(loadResult.Pkgs)),
 		pkgfile:  loadResult.Pkgfile,
+		variants: loadResult.Pkgfile.GetVariants(),
 	}
 
 	for _, pkg := range loadResult.Pkgs {
@@ -36,6 +41,15 @@ func NewPackages(loader PackageLoader) (*Packages, error) {
 			return nil, fmt.Errorf("package %q already exists, duplicate in dirs %q and %q", name, pkg.BaseDir, dup.BaseDir)
 		}
 
+		variant, exists := result.variants[pkg.Variant]
+		if !exists {
+			return nil, fmt.Errorf("package %q: variant %q is not defined", name, pkg.Variant)
+		}
+
+		if err := pkg.Install.ValidateFor(variant.PackageManager); err != nil {
+			return nil, fmt.Errorf("package %q: variant %q: %w", name, pkg.Variant, err)
+		}
+
 		result.packages[name] = pkg
 	}
 
@@ -51,16 +65,44 @@ func (pkgs *Packages) FilterInPlace(f func(pkg *v1alpha2.Pkg) bool) {
 	}
 }
 
-func (pkgs *Packages) resolve(name string, path []string, cache map[string]*PackageNode) (*PackageNode, error) {
-	if node := cache[name]; node != nil {
-		return node, nil
+// supports checks whether the package referenced by the stage supports the platform.
+func (pkgs *Packages) supports(stage, platform string) bool {
+	name, _ := v1alpha2.SplitStage(stage)
+	pkg := pkgs.packages[name]
+
+	return pkg == nil || platform == "" || pkg.Platforms.Supports(platform)
+}
+
+// resolveStage resolves the package referenced by the stage (name[:output]) and checks that the output exists.
+func (pkgs *Packages) resolveStage(stage, platform string, path []string, cache map[string]*PackageNode) (*PackageNode, error) {
+	name, output := v1alpha2.SplitStage(stage)
+
+	node, err := pkgs.resolve(name, platform, path, cache)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := node.Pkg.GetFinalize(output); !ok {
+		return nil, fmt.Errorf("package %q has no output %q", name, output)
 	}
 
+	return node, nil
+}
+
+func (pkgs *Packages) resolve(name, platform string, path []string, cache map[string]*PackageNode) (*PackageNode, error) {
 	pkg := pkgs.packages[name]
 	if pkg == nil {
 		return nil, fmt.Errorf("package %q not defined", name)
 	}
 
+	if !pkgs.supports(name, platform) {
+		return nil, fmt.Errorf("package %q doesn't support platform %q, supported platforms: %s", name, platform, pkg.Platforms)
+	}
+
+	if node := cache[name]; node != nil {
+		return node, nil
+	}
+
 	if slices.Contains(path, name) {
 		return nil, fmt.Errorf("circular dependency detected %v -> %q", path, name)
 	}
@@ -68,17 +110,29 @@ func (pkgs *Packages) resolve(name string, path []string, cache map[string]*Pack
 	path = append(path, name)
 
 	node := &PackageNode{
-		Pkg:  pkg,
-		Name: name,
+		Pkg:     pkg,
+		Name:    name,
+		Variant: pkgs.variants[pkg.Variant],
 	}
 
 	for _, dep := range pkg.Dependencies {
+		if !dep.When.Matches(pkg.Context) {
+			continue
+		}
+
 		nodeDep := PackageDependency{
 			Dependency: dep,
 		}
 
+		// dependencies are built for the build platform of the package, unless overridden
+		depPlatform := cmp.Or(dep.Platform, pkg.BuildPlatform, pkg.Context.GetString(constants.BuildPlatformVariable))
+
+		if dep.Optional && !pkgs.supports(dep.Stage, depPlatform) {
+			continue
+		}
+
 		if dep.IsInternal() {
-			depPkg, err := pkgs.resolve(dep.Stage, path, cache)
+			depPkg, err := pkgs.resolveStage(dep.Stage, depPlatform, path, cache)
 			if err != nil {
 				return nil, fmt.Errorf("error resolving dependency %q of %q: %w", dep.Stage, name, err)
 			}
@@ -89,19 +143,65 @@ func (pkgs *Packages) resolve(name string, path []string, cache map[string]*Pack
 		node.Dependencies = append(node.Dependencies, nodeDep)
 	}
 
+	for _, step := range pkg.Steps {
+		if !step.When.Matches(pkg.Context) {
+			continue
+		}
+
+		for _, mount := range step.Mounts {
+			if mount.Type != v1alpha2.MountTypeBind {
+				continue
+			}
+
+			if _, alreadyResolved := node.MountDependency(mount); alreadyResolved {
+				continue
+			}
+
+			nodeDep := PackageDependency{
+				Dependency: mount.Dependency(),
+			}
+
+			if nodeDep.IsInternal() {
+				depPkg, err := pkgs.resolveStage(nodeDep.Stage, cmp.Or(mount.Platform, pkg.BuildPlatform, pkg.Context.GetString(constants.BuildPlatformVariable)), path, cache)
+				if err != nil {
+					return nil, fmt.Errorf("error resolving mount %q of %q: %w", nodeDep.Stage, name, err)
+				}
+
+				nodeDep.Node = depPkg
+			}
+
+			node.Mounts = append(node.Mounts, nodeDep)
+		}
+	}
+
 	cache[name] = node
 
 	return node, nil
 }
 
 // Resolve trims down the package tree to have only deps of the target.
+//
+// Target might reference the package output (e.g. gcc:dev).
+// Resolve fails if the target or any of its dependencies doesn't support the target platform.
 func (pkgs *Packages) Resolve(target string) (*PackageGraph, error) {
-	root, err := pkgs.resolve(target, nil, make(map[string]*PackageNode))
+	var platform string
+
+	name, output := v1alpha2.SplitStage(target)
+
+	if pkg := pkgs.packages[name]; pkg != nil {
+		platform = pkg.Context.GetString(constants.TargetPlatformVariable)
+	}
+
+	root, err := pkgs.resolveStage(target, platform, nil, make(map[string]*PackageNode))
 	if err != nil {
 		return nil, err
 	}
 
-	return &PackageGraph{root}, nil
+	return &PackageGraph{
+		Root:         root,
+		Output:       output,
+		FetcherImage: cmp.Or(pkgs.variants[v1alpha2.Alpine].Image, constants.DefaultBaseImage),
+	}, nil
 }
 
 // ToSet converts to set of package nodes.
@@ -115,6 +215,7 @@ func (pkgs *Packages) ToSet() (set PackageSet) {
 		set = append(set, &PackageNode{
 			Name:         name,
 			Pkg:          pkg,
+			Variant:      pkgs.variants[pkg.Variant],
 			Dependencies: dependencies,
 		})
 	}
@@ -122,7 +223,38 @@ func (pkgs *Packages) ToSet() (set PackageSet) {
 	return set
 }
 
-// ImageLabels returns set of image labels to apply to the output image.
-func (pkgs *Packages) ImageLabels() map[string]string {
-	return pkgs.pkgfile.Labels
+// ImageConfig returns the output image configuration of the target package (might be nil).
+func (pkgs *Packages) ImageConfig(target string) *v1alpha2.Image {
+	name, _ := v1alpha2.SplitStage(target)
+
+	if pkg := pkgs.packages[name]; pkg != nil {
+		return pkg.Image
+	}
+
+	return nil
+}
+
+// ImageLabels returns set of image labels to apply to the output image of the target.
+//
+// Package metadata labels of the target take precedence over the Pkgfile labels.
+func (pkgs *Packages) ImageLabels(target string) map[string]string {
+	name, _ := v1alpha2.SplitStage(target)
+
+	pkg := pkgs.packages[name]
+	if pkg == nil {
+		return pkgs.pkgfile.Labels
+	}
+
+	labels := maps.Clone(pkgs.pkgfile.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+
+	maps.Copy(labels, pkg.MetadataLabels())
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	return labels
 }
@@ -0,0 +1,56 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package solver
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+// attachPatches verifies that patch files referenced by the package steps exist
+// in the package directory and attaches them to the package.
+//
+// Patch file might also be a templated file attached to the package.
+func attachPatches(pkg *v1alpha2.Pkg, readFile func(path string) ([]byte, error)) error {
+	var multiErr *multierror.Error
+
+	templatedFiles := make(map[string][]byte, len(pkg.GetTemplatedFiles()))
+
+	for _, templatedFile := range pkg.GetTemplatedFiles() {
+		templatedFiles[templatedFile.Path] = templatedFile.Content
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, step := range pkg.Steps {
+		for _, patch := range step.Patches {
+			if _, alreadyAttached := seen[patch.File]; alreadyAttached {
+				continue
+			}
+
+			seen[patch.File] = struct{}{}
+
+			contents, ok := templatedFiles[patch.File]
+			if !ok {
+				var err error
+
+				contents, err = readFile(filepath.Join(pkg.BaseDir, patch.File))
+				if err != nil {
+					multiErr = multierror.Append(multiErr, fmt.Errorf("error reading patch %q of package %q: %w", patch.File, pkg.Name, err))
+
+					continue
+				}
+			}
+
+			pkg.AttachPatchFile(patch.File, contents)
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
@@ -0,0 +1,100 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// OriginAction describes how the source changed the variable.
+type OriginAction string
+
+// Origin actions.
+const (
+	// OriginSet replaces the value of the variable.
+	OriginSet OriginAction = "set"
+	// OriginAppend appends items to the list (`NAME+`).
+	OriginAppend OriginAction = "append"
+	// OriginMerge merges the map into the existing map.
+	OriginMerge OriginAction = "merge"
+)
+
+// Origin describes how a source affected the variable.
+type Origin struct {
+	// Value set by the source (for appends and merges, only the value from the source).
+	Value  any
+	Source string
+	Action OriginAction
+}
+
+// Provenance merges variables from multiple sources, and tracks the origins of each variable.
+type Provenance struct {
+	vars    Variables
+	origins map[string][]Origin
+}
+
+// NewProvenance creates an empty Provenance.
+func NewProvenance() *Provenance {
+	return &Provenance{
+		vars:    Variables{},
+		origins: map[string][]Origin{},
+	}
+}
+
+// Merge the variables from the source, see [Variables.Merge].
+func (p *Provenance) Merge(source string, vars Variables) {
+	for key, value := range vars {
+		action := OriginSet
+
+		name, appended := strings.CutSuffix(key, AppendSuffix)
+
+		switch {
+		case appended:
+			action = OriginAppend
+		case isMap(value) && isMap(p.vars[name]):
+			action = OriginMerge
+		}
+
+		p.origins[name] = append(p.origins[name], Origin{
+			Source: source,
+			Value:  deepCopy(value),
+			Action: action,
+		})
+	}
+
+	p.vars.Merge(vars)
+}
+
+// Copy returns a deep copy of the provenance.
+func (p *Provenance) Copy() *Provenance {
+	origins := maps.Clone(p.origins)
+
+	for name, o := range origins {
+		origins[name] = slices.Clone(o)
+	}
+
+	return &Provenance{
+		vars:    p.vars.Copy(),
+		origins: origins,
+	}
+}
+
+// Variables returns the merged variables.
+func (p *Provenance) Variables() Variables {
+	return p.vars
+}
+
+// Origins returns the sources which set the variable, in the order of merging.
+func (p *Provenance) Origins(name string) []Origin {
+	return p.origins[name]
+}
+
+func isMap(value any) bool {
+	_, ok := value.(map[string]any)
+
+	return ok
+}
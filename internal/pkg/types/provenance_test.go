@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+func TestProvenance(t *testing.T) {
+	provenance := types.NewProvenance()
+
+	provenance.Merge("default", types.Variables{"SYSROOT": "/talos", "FLAGS": []any{"--a"}})
+	provenance.Merge("Pkgfile", types.Variables{"SYSROOT": "/test", "TRIPLETS": map[string]any{"amd64": "x86_64"}})
+	provenance.Merge("vars.yaml", types.Variables{"FLAGS+": []any{"--b"}, "TRIPLETS": map[string]any{"arm64": "aarch64"}})
+
+	assert.Equal(t, types.Variables{
+		"SYSROOT":  "/test",
+		"FLAGS":    []any{"--a", "--b"},
+		"TRIPLETS": map[string]any{"amd64": "x86_64", "arm64": "aarch64"},
+	}, provenance.Variables())
+
+	assert.Equal(t, []types.Origin{
+		{Source: "default", Value: "/talos", Action: types.OriginSet},
+		{Source: "Pkgfile", Value: "/test", Action: types.OriginSet},
+	}, provenance.Origins("SYSROOT"))
+
+	assert.Equal(t, []types.Origin{
+		{Source: "default", Value: []any{"--a"}, Action: types.OriginSet},
+		{Source: "vars.yaml", Value: []any{"--b"}, Action: types.OriginAppend},
+	}, provenance.Origins("FLAGS"))
+
+	assert.Equal(t, []types.Origin{
+		{Source: "Pkgfile", Value: map[string]any{"amd64": "x86_64"}, Action: types.OriginSet},
+		{Source: "vars.yaml", Value: map[string]any{"arm64": "aarch64"}, Action: types.OriginMerge},
+	}, provenance.Origins("TRIPLETS"))
+
+	assert.Empty(t, provenance.Origins("MISSING"))
+
+	copied := provenance.Copy()
+	copied.Merge("subdir/vars.yaml", types.Variables{"FLAGS+": []any{"--c"}})
+
+	assert.Equal(t, []any{"--a", "--b", "--c"}, copied.Variables()["FLAGS"])
+	assert.Equal(t, []any{"--a", "--b"}, provenance.Variables()["FLAGS"])
+	assert.Len(t, copied.Origins("FLAGS"), 3)
+	assert.Len(t, provenance.Origins("FLAGS"), 2)
+}
@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/hashicorp/go-multierror"
+)
+
+// Render executes the template with the variables as the context.
+//
+// Templates are executed with `missingkey=error`, so that a reference to the undefined variable
+// fails instead of rendering as `<no value>`.
+// If execution fails, all undefined variables hit during the execution are reported at once.
+func (v Variables) Render(name string, contents []byte) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Funcs(sprig.HermeticTxtFuncMap()).
+		Option("missingkey=error").
+		Parse(string(contents))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err = tmpl.Execute(&buf, v); err != nil {
+		if undefinedErr := v.undefinedVariables(tmpl, err); undefinedErr != nil {
+			return nil, undefinedErr
+		}
+
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var missingKeyRe = regexp.MustCompile(`^template: (.+?): executing ".*" at <.*>: map has no entry for key "(.*)"$`)
+
+// undefinedVariables re-executes the template with each undefined variable stubbed out,
+// until the execution stops failing on the undefined variables.
+//
+// Only the variables which were actually hit during the execution are reported,
+// so references guarded with e.g. `{{ if index . "NAME" }}` are not.
+func (v Variables) undefinedVariables(tmpl *template.Template, err error) error {
+	type undefined struct {
+		location string
+		name     string
+	}
+
+	var reported []undefined
+
+	stubbed := maps.Clone(v)
+
+	for err != nil {
+		matches := missingKeyRe.FindStringSubmatch(err.Error())
+		if matches == nil {
+			break
+		}
+
+		location, name := matches[1], matches[2]
+
+		if _, exists := stubbed[name]; exists {
+			// stubbing didn't help, so the missing key is not a variable (e.g. a key of the map variable)
+			if len(reported) > 0 && reported[len(reported)-1].name == name {
+				reported = reported[:len(reported)-1]
+			}
+
+			break
+		}
+
+		reported = append(reported, undefined{location: location, name: name})
+		stubbed[name] = ""
+
+		err = tmpl.Execute(io.Discard, stubbed)
+	}
+
+	var multiErr *multierror.Error
+
+	for _, u := range reported {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("%s: variable %q is not defined", u.location, u.name))
+	}
+
+	return multiErr.ErrorOrNil()
+}
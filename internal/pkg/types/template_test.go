@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+func TestVariablesRender(t *testing.T) {
+	vars := types.Variables{
+		"ARCH":  "amd64",
+		"FLAGS": []any{"--a", "--b"},
+	}
+
+	rendered, err := vars.Render("pkg.yaml", []byte(`{{ .ARCH }}{{ range .FLAGS }} {{ . }}{{ end }} {{ index . "MISSING" | default "none" }}`))
+	require.NoError(t, err)
+	assert.Equal(t, "amd64 --a --b none", string(rendered))
+
+	_, err = vars.Render("pkg.yaml", []byte(`{{ .ARCH }}
+{{ .MISSING }} {{ .ARCH }}
+{{ if .OTHER }}{{ $.MISSING }}{{ end }}{{ range .FLAGS }}{{ .NOT_A_VARIABLE }}{{ end }}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `2 errors occurred`)
+	assert.Contains(t, err.Error(), `pkg.yaml:2:3: variable "MISSING" is not defined`)
+	assert.Contains(t, err.Error(), `pkg.yaml:3:6: variable "OTHER" is not defined`)
+
+	// guarded references are not reported
+	_, err = vars.Render("pkg.yaml", []byte(`{{ if index . "EXTRA" }}{{ .EXTRA }}{{ end }}{{ .TYPO }}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `pkg.yaml:1:48: variable "TYPO" is not defined`)
+	assert.NotContains(t, err.Error(), `EXTRA`)
+
+	// missing keys of the map variables are not variables
+	_, err = types.Variables{"MAP": map[string]any{"a": "b"}}.Render("pkg.yaml", []byte(`{{ with .MAP }}{{ .c }}{{ end }}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `map has no entry for key "c"`)
+	assert.NotContains(t, err.Error(), `is not defined`)
+}
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+// Condition limits a step, dependency or finalize instruction to the matching
+// target platforms and variables.
+//
+// All the set fields should match for the condition to match,
+// nil condition always matches.
+type Condition struct {
+	// Platforms is a list of target platforms (e.g. linux/amd64).
+	Platforms Platforms `yaml:"platforms,omitempty"`
+	// Vars should be equal to the given values.
+	Vars map[string]string `yaml:"vars,omitempty"`
+	// Defined variables should be set to a non-empty value.
+	Defined []string `yaml:"defined,omitempty"`
+}
+
+// Matches evaluates the condition against the package variables.
+func (c *Condition) Matches(vars types.Variables) bool {
+	if c == nil {
+		return true
+	}
+
+	if !c.Platforms.Supports(vars.GetString(constants.TargetPlatformVariable)) {
+		return false
+	}
+
+	for name, value := range c.Vars {
+		if vars.GetString(name) != value {
+			return false
+		}
+	}
+
+	for _, name := range c.Defined {
+		if value, ok := vars[name]; !ok || value == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns human-readable representation of the condition.
+func (c *Condition) String() string {
+	if c == nil {
+		return ""
+	}
+
+	var parts []string
+
+	if len(c.Platforms) > 0 {
+		parts = append(parts, c.Platforms.String())
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Vars)) {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, c.Vars[name]))
+	}
+
+	for _, name := range c.Defined {
+		parts = append(parts, fmt.Sprintf("defined(%s)", name))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Validate the condition.
+func (c *Condition) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	multiErr := multierror.Append(nil, c.Platforms.Validate())
+
+	if len(c.Platforms) == 0 && len(c.Vars) == 0 && len(c.Defined) == 0 {
+		multiErr = multierror.Append(multiErr, errors.New("when: condition should have platforms, vars or defined set"))
+	}
+
+	return multiErr.ErrorOrNil()
+}
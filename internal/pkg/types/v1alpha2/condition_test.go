@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestConditionMatches(t *testing.T) {
+	vars := types.Variables{
+		"TARGETPLATFORM": "linux/arm64",
+		"ARCH":           "aarch64",
+		"WITH_DEBUG":     "true",
+		"EMPTY":          "",
+	}
+
+	for _, test := range []struct {
+		name      string
+		condition *v1alpha2.Condition
+		expected  bool
+	}{
+		{
+			name:     "nil",
+			expected: true,
+		},
+		{
+			name:      "platform",
+			condition: &v1alpha2.Condition{Platforms: []string{"linux/amd64", "linux/arm64"}},
+			expected:  true,
+		},
+		{
+			name:      "platform not normalized",
+			condition: &v1alpha2.Condition{Platforms: []string{"linux/arm64/v8"}},
+			expected:  true,
+		},
+		{
+			name:      "other platform",
+			condition: &v1alpha2.Condition{Platforms: []string{"linux/amd64"}},
+		},
+		{
+			name:      "vars",
+			condition: &v1alpha2.Condition{Vars: map[string]string{"ARCH": "aarch64", "WITH_DEBUG": "true"}},
+			expected:  true,
+		},
+		{
+			name:      "vars mismatch",
+			condition: &v1alpha2.Condition{Platforms: []string{"linux/arm64"}, Vars: map[string]string{"WITH_DEBUG": "false"}},
+		},
+		{
+			name:      "defined",
+			condition: &v1alpha2.Condition{Defined: []string{"WITH_DEBUG"}},
+			expected:  true,
+		},
+		{
+			name:      "empty is not defined",
+			condition: &v1alpha2.Condition{Defined: []string{"EMPTY"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.condition.Matches(vars))
+		})
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	require.NoError(t, (*v1alpha2.Condition)(nil).Validate())
+	require.NoError(t, (&v1alpha2.Condition{Platforms: []string{"linux/arm64"}}).Validate())
+
+	err := (&v1alpha2.Condition{Platforms: []string{"arm64"}}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `platform should be in os/arch format: "arm64"`)
+
+	err = (&v1alpha2.Condition{}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "when: condition should have platforms, vars or defined set")
+}
+
+func TestConditionString(t *testing.T) {
+	assert.Equal(t, "", (*v1alpha2.Condition)(nil).String())
+	assert.Equal(t,
+		"linux/amd64,linux/arm64 A=1 B=2 defined(C)",
+		(&v1alpha2.Condition{
+			Platforms: []string{"linux/amd64", "linux/arm64"},
+			Vars:      map[string]string{"B": "2", "A": "1"},
+			Defined:   []string{"C"},
+		}).String(),
+	)
+}
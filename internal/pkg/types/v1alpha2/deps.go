@@ -6,18 +6,23 @@ package v1alpha2
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 )
 
 // Dependency on another image or stage.
 type Dependency struct {
-	Image    string `yaml:"image,omitempty"`
-	Stage    string `yaml:"stage,omitempty"`
-	From     string `yaml:"from,omitempty"`
-	To       string `yaml:"to,omitempty"`
-	Platform string `yaml:"platform,omitempty"`
-	Runtime  bool   `yaml:"runtime,omitempty"`
+	When     *Condition `yaml:"when,omitempty"`
+	Image    string     `yaml:"image,omitempty"`
+	Stage    string     `yaml:"stage,omitempty"`
+	From     string     `yaml:"from,omitempty"`
+	To       string     `yaml:"to,omitempty"`
+	Platform string     `yaml:"platform,omitempty"`
+	Include  []string   `yaml:"include,omitempty"`
+	Exclude  []string   `yaml:"exclude,omitempty"`
+	Runtime  bool       `yaml:"runtime,omitempty"`
+	Optional bool       `yaml:"optional,omitempty"`
 }
 
 // IsInternal checks whether dependency is internal to some stage.
@@ -25,6 +30,35 @@ func (d *Dependency) IsInternal() bool {
 	return d.Stage != ""
 }
 
+// StageName returns name of the package referenced by the stage.
+func (d *Dependency) StageName() string {
+	name, _ := SplitStage(d.Stage)
+
+	return name
+}
+
+// Output returns name of the package output referenced by the stage, empty for the default output.
+func (d *Dependency) Output() string {
+	_, output := SplitStage(d.Stage)
+
+	return output
+}
+
+// ID returns unique string for the dependency: dependencies with the same ID produce the same build input.
+func (d *Dependency) ID() string {
+	id := fmt.Sprintf("%s-%s-%s", d.Image, d.Stage, d.To)
+
+	if d.Platform != "" {
+		id += "-" + d.Platform
+	}
+
+	if d.HasFilters() {
+		id += fmt.Sprintf("-%q-%q", d.Include, d.Exclude)
+	}
+
+	return id
+}
+
 // Src returns copy source (from dependency).
 func (d *Dependency) Src() string {
 	if d.From != "" {
@@ -34,6 +68,11 @@ func (d *Dependency) Src() string {
 	return "/"
 }
 
+// HasFilters returns true if dependency contents are filtered with include or exclude patterns.
+func (d *Dependency) HasFilters() bool {
+	return len(d.Include) > 0 || len(d.Exclude) > 0
+}
+
 // Dest returns copy destination (to base).
 func (d *Dependency) Dest() string {
 	if d.To != "" {
@@ -53,7 +92,19 @@ func (d *Dependency) Validate() error {
 		return fmt.Errorf("either image or stage should be set for the dependency")
 	}
 
-	return nil
+	if name, output := SplitStage(d.Stage); strings.Contains(d.Stage, OutputSeparator) && (name == "" || output == "") {
+		return fmt.Errorf("dependency stage should be in name[:output] format: %q", d.Stage)
+	}
+
+	if d.Optional && d.Stage == "" {
+		return fmt.Errorf("only stage dependency can be optional: %q", d.Image)
+	}
+
+	if err := validatePatterns(d.Include, d.Exclude); err != nil {
+		return err
+	}
+
+	return d.When.Validate()
 }
 
 // Dependencies is a list of Depency.
@@ -0,0 +1,198 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/siderolabs/gen/xslices"
+)
+
+// Extend merges the template package into the package.
+//
+// Merge rules:
+//   - `install` packages and repositories, `dependencies` are appended to the template ones,
+//     a package dependency with the same ID as a template dependency replaces it;
+//   - `env` is merged, package variables override template variables;
+//   - `steps` are merged by index: fields set in the package step override the template step fields,
+//     step `env` is merged the same way as package `env`, extra steps of the package are appended;
+//   - other fields (`variant`, `shell`, `platforms`, `finalize`, `outputs`, `image`, metadata, ...)
+//     are inherited from the template unless set in the package.
+//
+// Templated files and patches are not inherited, patches are looked up relative to the package.
+func (p *Pkg) Extend(tmpl *Pkg) {
+	p.Install = Install{
+		Packages:     dedup(slices.Concat(tmpl.Install.Packages, p.Install.Packages)),
+		Repositories: slices.Concat(tmpl.Install.Repositories, p.Install.Repositories),
+	}
+
+	p.Dependencies = mergeDependencies(tmpl.Dependencies, p.Dependencies)
+	p.Env = mergeEnv(tmpl.Env, p.Env)
+
+	steps := make(Steps, max(len(tmpl.Steps), len(p.Steps)))
+
+	for i := range steps {
+		switch {
+		case i >= len(p.Steps):
+			steps[i] = tmpl.Steps[i]
+		case i >= len(tmpl.Steps):
+			steps[i] = p.Steps[i]
+		default:
+			steps[i] = p.Steps[i]
+			steps[i].Env = mergeEnv(tmpl.Steps[i].Env, p.Steps[i].Env)
+			inheritZeroFields(&steps[i], &tmpl.Steps[i])
+		}
+	}
+
+	p.Steps = steps
+
+	inheritZeroFields(p, tmpl, "TemplatedFiles", "PatchFiles", "Context", "Name", "Abstract", "Extends", "BaseDir", "FileName")
+}
+
+// inheritZeroFields sets zero fields of dst (pointer to struct) to the values of the same fields of src.
+func inheritZeroFields[T any](dst, src *T, skip ...string) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src).Elem()
+
+	for i := range dstValue.NumField() {
+		if slices.Contains(skip, dstValue.Type().Field(i).Name) {
+			continue
+		}
+
+		if field := dstValue.Field(i); field.IsZero() {
+			field.Set(srcValue.Field(i))
+		}
+	}
+}
+
+// mergeEnv returns the template environment overridden by the package environment.
+func mergeEnv(tmpl, env Environment) Environment {
+	if len(tmpl) == 0 {
+		return env
+	}
+
+	result := maps.Clone(tmpl)
+	maps.Copy(result, env)
+
+	return result
+}
+
+// mergeDependencies appends the package dependencies to the template ones,
+// a package dependency with the same ID replaces the template dependency in place.
+func mergeDependencies(tmpl, deps Dependencies) Dependencies {
+	result := slices.Clone(tmpl)
+
+	for _, dep := range deps {
+		if idx := slices.IndexFunc(result, func(d Dependency) bool { return d.ID() == dep.ID() }); idx != -1 {
+			result[idx] = dep
+		} else {
+			result = append(result, dep)
+		}
+	}
+
+	return result
+}
+
+func dedup(items []string) []string {
+	result := make([]string, 0, len(items))
+
+	for _, item := range items {
+		if !slices.Contains(result, item) {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// ResolveExtends merges templates into the packages which extend them, and validates the resulting packages.
+//
+// Abstract templates are removed from the result, templates can't have templated files.
+// Packages which failed to be resolved or validated are skipped, and errors are returned.
+func ResolveExtends(pkgs []*Pkg) ([]*Pkg, error) {
+	var multiErr *multierror.Error
+
+	templates := map[string]*Pkg{}
+
+	for _, pkg := range pkgs {
+		if !pkg.Abstract {
+			continue
+		}
+
+		if dup, exists := templates[pkg.Name]; exists {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("template %q already exists, duplicate in dirs %q and %q", pkg.Name, pkg.BaseDir, dup.BaseDir))
+
+			continue
+		}
+
+		templates[pkg.Name] = pkg
+
+		// templated files are rendered for the template context, and they are not inherited
+		if len(pkg.TemplatedFiles) > 0 {
+			paths := xslices.Map(pkg.TemplatedFiles, func(f TemplatedFile) string { return f.Path })
+
+			multiErr = multierror.Append(multiErr, fmt.Errorf("template %q in %q has templated files %q, which are not inherited by the packages extending it", pkg.Name, pkg.BaseDir, paths))
+		}
+	}
+
+	resolved := map[*Pkg]struct{}{}
+
+	// path is the chain of the templates being resolved
+	var resolve func(pkg *Pkg, path []string) error
+
+	resolve = func(pkg *Pkg, path []string) error {
+		if _, ok := resolved[pkg]; ok || pkg.Extends == "" {
+			return nil
+		}
+
+		if slices.Contains(path, pkg.Extends) {
+			return fmt.Errorf("circular extends detected %v -> %q", path, pkg.Extends)
+		}
+
+		tmpl, ok := templates[pkg.Extends]
+		if !ok {
+			return fmt.Errorf("template %q is not defined (templates should have `abstract: true`)", pkg.Extends)
+		}
+
+		if err := resolve(tmpl, append(path, pkg.Extends)); err != nil {
+			return err
+		}
+
+		pkg.Extend(tmpl)
+		resolved[pkg] = struct{}{}
+
+		return nil
+	}
+
+	result := make([]*Pkg, 0, len(pkgs))
+
+	for _, pkg := range pkgs {
+		if pkg.Abstract {
+			continue
+		}
+
+		if err := resolve(pkg, nil); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error resolving extends of %q in %q: %w", pkg.Name, pkg.BaseDir, err))
+
+			continue
+		}
+
+		pkg.SetDefaults()
+
+		if err := pkg.Validate(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error validating %q in %q: %w", pkg.Name, pkg.BaseDir, err))
+
+			continue
+		}
+
+		result = append(result, pkg)
+	}
+
+	return result, multiErr.ErrorOrNil()
+}
@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/siderolabs/gen/xslices"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func parsePkgs(t *testing.T, contents ...string) []*v1alpha2.Pkg {
+	t.Helper()
+
+	pkgs := make([]*v1alpha2.Pkg, 0, len(contents))
+
+	for _, c := range contents {
+		pkg, err := v1alpha2.ParsePkg("", "", []byte(c), types.Variables{})
+		require.NoError(t, err)
+
+		pkgs = append(pkgs, pkg)
+	}
+
+	return pkgs
+}
+
+func TestResolveExtends(t *testing.T) {
+	pkgs, err := v1alpha2.ResolveExtends(parsePkgs(t,
+		`name: base
+abstract: true
+variant: alpine
+install:
+  - make
+env:
+  CFLAGS: -O2
+`,
+		`name: autotools
+abstract: true
+extends: base
+install:
+  - autoconf
+dependencies:
+  - stage: libc
+steps:
+  - env:
+      MAKEFLAGS: -j4
+      V: "0"
+    prepare:
+      - ./configure
+    build:
+      - make
+    install:
+      - make install DESTDIR=/rootfs
+finalize:
+  - from: /rootfs
+    to: /
+`,
+		`name: zlib
+extends: autotools
+install:
+  - make
+  - pkgconf
+env:
+  CFLAGS: -O3
+dependencies:
+  - stage: libc
+steps:
+  - env:
+      V: "1"
+    sources:
+      - url: https://zlib.net/zlib-1.3.1.tar.gz
+        destination: zlib.tar.gz
+        sha256: 9a93b2b7dfdac77ceba5a558a580e74667dd6fede4585b91eefb60f03b72df23
+        sha512: 580677aad97093829090d4b605ac81c50327e74a6c2de0b85dd2e8525553f3ddde17556ea46f8f007f89e435493c9a20bc997d1ef1c1c2c23274528e3c46b94f
+    install:
+      - make install DESTDIR=/rootfs/usr
+  - test:
+      - test -f /rootfs/usr/lib/libz.so
+`,
+		`name: libc
+variant: scratch
+`,
+	))
+	require.NoError(t, err)
+	require.Len(t, pkgs, 2)
+
+	zlib := pkgs[0]
+
+	assert.Equal(t, v1alpha2.Variant("alpine"), zlib.Variant)
+	assert.Equal(t, v1alpha2.Shell("/bin/sh"), zlib.Shell)
+	assert.Equal(t, []string{"make", "autoconf", "pkgconf"}, zlib.Install.Packages)
+	assert.Equal(t, v1alpha2.Environment{"CFLAGS": "-O3"}, zlib.Env)
+	require.Len(t, zlib.Dependencies, 1)
+	assert.Equal(t, "libc", zlib.Dependencies[0].Stage)
+	assert.Equal(t, []v1alpha2.Finalize{{From: "/rootfs", To: "/"}}, zlib.Finalize)
+
+	require.Len(t, zlib.Steps, 2)
+	assert.Len(t, zlib.Steps[0].Sources, 1)
+	assert.Equal(t, v1alpha2.Instructions{"./configure"}, zlib.Steps[0].Prepare)
+	assert.Equal(t, v1alpha2.Instructions{"make install DESTDIR=/rootfs/usr"}, zlib.Steps[0].Install)
+	assert.Equal(t, v1alpha2.Environment{"MAKEFLAGS": "-j4", "V": "1"}, zlib.Steps[0].Env)
+	assert.Equal(t, v1alpha2.Instructions{"test -f /rootfs/usr/lib/libz.so"}, zlib.Steps[1].Test)
+}
+
+func TestResolveExtendsErrors(t *testing.T) {
+	pkgs := parsePkgs(t,
+		`name: a
+abstract: true
+extends: b
+`,
+		`name: b
+abstract: true
+extends: a
+`,
+		`name: circular
+extends: a
+`,
+		`name: missing
+extends: autotools
+variant: alpine
+`,
+		`name: concrete
+variant: alpine
+`,
+		`name: invalid
+extends: concrete
+`,
+		`name: novariant
+`,
+		`name: templated
+abstract: true
+`,
+	)
+
+	require.NoError(t, pkgs[len(pkgs)-1].AttachTemplatedFile("config.h.tmpl", []byte("#define X 1\n")))
+
+	pkgs, err := v1alpha2.ResolveExtends(pkgs)
+	require.Error(t, err)
+	assert.Equal(t, []string{"concrete"}, xslices.Map(pkgs, func(pkg *v1alpha2.Pkg) string { return pkg.Name }))
+
+	assert.Contains(t, err.Error(), `error resolving extends of "circular" in "": circular extends detected [a b] -> "a"`)
+	assert.Contains(t, err.Error(), `error resolving extends of "missing" in "": template "autotools" is not defined`)
+	assert.Contains(t, err.Error(), `error resolving extends of "invalid" in "": template "concrete" is not defined`)
+	assert.Contains(t, err.Error(), `error validating "novariant" in "": 1 error occurred:`)
+	assert.Contains(t, err.Error(), `template "templated" in "" has templated files ["config.h"], which are not inherited by the packages extending it`)
+}
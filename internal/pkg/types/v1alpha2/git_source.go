@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// GitSource describes Git repository checkout pinned to a commit.
+type GitSource struct {
+	Repository string `yaml:"repository,omitempty"`
+	Ref        string `yaml:"ref,omitempty"`
+	Commit     string `yaml:"commit,omitempty"`
+	Submodules bool   `yaml:"submodules,omitempty"`
+	KeepGitDir bool   `yaml:"keepGitDir,omitempty"`
+}
+
+// GetRef returns the ref to checkout, defaults to the commit.
+func (git *GitSource) GetRef() string {
+	if git.Ref != "" {
+		return git.Ref
+	}
+
+	return git.Commit
+}
+
+// DigestAlgorithm returns hash algorithm of the commit (sha1 or sha256 object format).
+func (git *GitSource) DigestAlgorithm() string {
+	if len(git.Commit) == 64 { //nolint:mnd
+		return "sha256"
+	}
+
+	return "sha1"
+}
+
+// Validate git source.
+func (git *GitSource) Validate() error {
+	var multiErr *multierror.Error
+
+	if git.Repository == "" {
+		multiErr = multierror.Append(multiErr, errors.New("source.git.repository can't be empty"))
+	}
+
+	switch len(git.Commit) {
+	case 0:
+		multiErr = multierror.Append(multiErr, errors.New("source.git.commit can't be empty"))
+	case 40, 64: //nolint:mnd
+		if _, err := hex.DecodeString(git.Commit); err != nil || strings.ToLower(git.Commit) != git.Commit {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("source.git.commit should be a lowercase hex commit hash: %q", git.Commit))
+		}
+	default:
+		multiErr = multierror.Append(multiErr, errors.New("source.git.commit should be a full commit hash (40 or 64 chars long)"))
+	}
+
+	return multiErr.ErrorOrNil()
+}
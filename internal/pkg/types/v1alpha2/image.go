@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// portRe matches `port` or `port/protocol`.
+var portRe = regexp.MustCompile(`^[0-9]{1,5}(/(tcp|udp|sctp))?$`)
+
+// Image is the configuration of the output image of the package (only in frontend mode).
+type Image struct {
+	Entrypoint   []string          `yaml:"entrypoint,omitempty"`
+	Cmd          []string          `yaml:"cmd,omitempty"`
+	Env          Environment       `yaml:"env,omitempty"`
+	WorkDir      string            `yaml:"workdir,omitempty"`
+	User         string            `yaml:"user,omitempty"`
+	ExposedPorts []string          `yaml:"exposedPorts,omitempty"`
+	Labels       map[string]string `yaml:"labels,omitempty"`
+	Annotations  map[string]string `yaml:"annotations,omitempty"`
+}
+
+// GetEnv returns the environment variables in `KEY=value` format sorted by key.
+func (img *Image) GetEnv() []string {
+	env := make([]string, 0, len(img.Env))
+
+	for _, key := range slices.Sorted(maps.Keys(img.Env)) {
+		env = append(env, key+"="+img.Env[key])
+	}
+
+	return env
+}
+
+// GetExposedPorts returns exposed ports with the protocol (defaults to `tcp`).
+func (img *Image) GetExposedPorts() []string {
+	ports := make([]string, 0, len(img.ExposedPorts))
+
+	for _, port := range img.ExposedPorts {
+		if !strings.Contains(port, "/") {
+			port += "/tcp"
+		}
+
+		ports = append(ports, port)
+	}
+
+	return ports
+}
+
+// Validate the image configuration.
+func (img *Image) Validate() error {
+	if img == nil {
+		return nil
+	}
+
+	var multiErr *multierror.Error
+
+	for key := range img.Env {
+		if key == "" || strings.Contains(key, "=") {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("image env variable name is invalid: %q", key))
+		}
+	}
+
+	if img.WorkDir != "" && !filepath.IsAbs(img.WorkDir) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("image workdir should be absolute: %q", img.WorkDir))
+	}
+
+	if img.User != "" && !userRe.MatchString(img.User) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("image user should be in uid[:gid] format: %q", img.User))
+	}
+
+	for _, port := range img.ExposedPorts {
+		if !portRe.MatchString(port) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("image exposed port should be in port[/tcp|udp|sctp] format: %q", port))
+
+			continue
+		}
+
+		number, _, _ := strings.Cut(port, "/")
+
+		if n, _ := strconv.Atoi(number); n < 1 || n > 65535 { //nolint:errcheck
+			multiErr = multierror.Append(multiErr, fmt.Errorf("image exposed port should be in 1-65535 range: %q", port))
+		}
+	}
+
+	for key := range img.Annotations {
+		if key == "" {
+			multiErr = multierror.Append(multiErr, errors.New("image annotation key can't be empty"))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
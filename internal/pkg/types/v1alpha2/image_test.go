@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestImage(t *testing.T) {
+	img := v1alpha2.Image{
+		Env: v1alpha2.Environment{
+			"PATH":     "/bin",
+			"GREETING": "hello",
+		},
+		ExposedPorts: []string{"8080", "53/udp"},
+	}
+
+	require.NoError(t, img.Validate())
+	assert.Equal(t, []string{"GREETING=hello", "PATH=/bin"}, img.GetEnv())
+	assert.Equal(t, []string{"8080/tcp", "53/udp"}, img.GetExposedPorts())
+
+	// image is optional
+	require.NoError(t, (*v1alpha2.Image)(nil).Validate())
+}
+
+func TestImageValidate(t *testing.T) {
+	img := v1alpha2.Image{
+		Env:          v1alpha2.Environment{"A=B": "C"},
+		WorkDir:      "srv",
+		User:         "nobody user",
+		ExposedPorts: []string{"http", "0/udp", "65536"},
+		Annotations:  map[string]string{"": "value"},
+	}
+
+	err := img.Validate()
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), `image env variable name is invalid: "A=B"`)
+	assert.Contains(t, err.Error(), `image workdir should be absolute: "srv"`)
+	assert.Contains(t, err.Error(), `image exposed port should be in port[/tcp|udp|sctp] format: "http"`)
+	assert.Contains(t, err.Error(), `image user should be in uid[:gid] format: "nobody user"`)
+	assert.Contains(t, err.Error(), `image exposed port should be in 1-65535 range: "0/udp"`)
+	assert.Contains(t, err.Error(), `image exposed port should be in 1-65535 range: "65536"`)
+	assert.Contains(t, err.Error(), "image annotation key can't be empty")
+}
@@ -0,0 +1,226 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// PackageManager is a package manager of the variant used to install packages listed in the `install` section.
+type PackageManager string
+
+// Supported package managers.
+const (
+	PackageManagerNone PackageManager = ""
+	PackageManagerAPK  PackageManager = "apk"
+	PackageManagerAPT  PackageManager = "apt"
+	PackageManagerDNF  PackageManager = "dnf"
+	// PackageManagerCustom runs the `install` command of the variant with the packages appended.
+	PackageManagerCustom PackageManager = "custom"
+)
+
+func (pm PackageManager) String() string {
+	return string(pm)
+}
+
+// Validate the package manager.
+func (pm PackageManager) Validate() error {
+	switch pm {
+	case PackageManagerNone, PackageManagerAPK, PackageManagerAPT, PackageManagerDNF, PackageManagerCustom:
+		return nil
+	default:
+		return fmt.Errorf("unsupported package manager %q, supported: %q", pm, []PackageManager{PackageManagerAPK, PackageManagerAPT, PackageManagerDNF, PackageManagerCustom})
+	}
+}
+
+// PackageVersionSeparator separates package name and pinned version (e.g. `make=4.4.1-r2`).
+const PackageVersionSeparator = "="
+
+var (
+	packageNameRe    = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._+:-]*$`)
+	packageVersionRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._+~:-]*$`)
+	repositoryNameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]*$`)
+)
+
+// SplitPackage splits package spec into name and pinned version, version is empty if not pinned.
+func SplitPackage(spec string) (name, version string) {
+	name, version, _ = strings.Cut(spec, PackageVersionSeparator)
+
+	return name, version
+}
+
+// Install describes packages installed with the package manager of the variant.
+//
+// It can be set either to a list of packages, or to a mapping with extra repositories.
+//
+//nolint:recvcheck
+type Install struct {
+	// Packages to install, each package might be pinned to a version with `name=version`.
+	Packages []string `yaml:"packages,omitempty" json:"packages,omitempty"`
+	// Repositories are extra package repositories enabled for the install.
+	Repositories []Repository `yaml:"repositories,omitempty" json:"repositories,omitempty"`
+}
+
+// IsZero implements yaml.IsZeroer interface.
+func (install Install) IsZero() bool {
+	return len(install.Packages) == 0 && len(install.Repositories) == 0
+}
+
+// Validate the install section.
+func (install Install) Validate() error {
+	var multiErr *multierror.Error
+
+	for _, spec := range install.Packages {
+		name, version := SplitPackage(spec)
+
+		if !packageNameRe.MatchString(name) || (strings.Contains(spec, PackageVersionSeparator) && !packageVersionRe.MatchString(version)) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("install package should be in name[=version] format: %q", spec))
+		}
+	}
+
+	if len(install.Packages) == 0 && len(install.Repositories) > 0 {
+		multiErr = multierror.Append(multiErr, errors.New("install repositories are set without packages"))
+	}
+
+	names := map[string]struct{}{}
+
+	for _, repo := range install.Repositories {
+		if _, exists := names[repo.Name]; exists {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("duplicate install repository %q", repo.Name))
+		}
+
+		names[repo.Name] = struct{}{}
+
+		multiErr = multierror.Append(multiErr, repo.Validate())
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// ValidateFor validates the install section against the package manager of the variant.
+func (install Install) ValidateFor(pm PackageManager) error {
+	if install.IsZero() {
+		return nil
+	}
+
+	if pm == PackageManagerNone {
+		return errors.New("install is not supported, variant has no package manager")
+	}
+
+	if pm == PackageManagerCustom && len(install.Repositories) > 0 {
+		return fmt.Errorf("install repositories are not supported for %s package manager", pm)
+	}
+
+	var multiErr *multierror.Error
+
+	for _, repo := range install.Repositories {
+		if pm == PackageManagerAPT && repo.Suite == "" {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("install repository %q should have suite set for %s", repo.Name, pm))
+		}
+
+		if pm != PackageManagerAPT && (repo.Suite != "" || len(repo.Components) > 0) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("install repository %q: suite and components are supported only for %s", repo.Name, PackageManagerAPT))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// UnmarshalYAML implements yaml.Unmarshaller interface.
+func (install *Install) UnmarshalYAML(unmarshal func(any) error) error {
+	var packages []string
+
+	if err := unmarshal(&packages); err == nil {
+		*install = Install{Packages: packages}
+
+		return nil
+	}
+
+	type installAlias Install
+
+	var aux installAlias
+
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	*install = Install(aux)
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaller interface.
+func (install Install) MarshalYAML() (any, error) {
+	if len(install.Repositories) == 0 {
+		return install.Packages, nil
+	}
+
+	type installAlias Install
+
+	return installAlias(install), nil
+}
+
+// MarshalJSON implements json.Marshaler interface.
+//
+// Install is marshaled the same way as in YAML: as a list of packages if there are no repositories.
+func (install Install) MarshalJSON() ([]byte, error) {
+	if len(install.Repositories) == 0 {
+		return json.Marshal(install.Packages)
+	}
+
+	type installAlias Install
+
+	return json.Marshal(installAlias(install))
+}
+
+// Repository is an extra package repository signed with the key.
+type Repository struct {
+	// Name of the repository, used to name the repository and key files.
+	//
+	// For `apk`, it should match the name of the key the repository is signed with (without `.rsa.pub`).
+	Name string `yaml:"name" json:"name"`
+	// URL of the repository.
+	URL string `yaml:"url" json:"url"`
+	// Suite of the repository (`apt` only, e.g. `bookworm`).
+	Suite string `yaml:"suite,omitempty" json:"suite,omitempty"`
+	// Components of the repository (`apt` only), defaults to `main`.
+	Components []string `yaml:"components,omitempty" json:"components,omitempty"`
+	// Key is the public signing key of the repository (PEM or ASCII-armored).
+	Key string `yaml:"key" json:"key"`
+}
+
+// GetComponents returns the components of the repository.
+func (repo *Repository) GetComponents() []string {
+	if len(repo.Components) > 0 {
+		return repo.Components
+	}
+
+	return []string{"main"}
+}
+
+// Validate the repository.
+func (repo *Repository) Validate() error {
+	var multiErr *multierror.Error
+
+	if !repositoryNameRe.MatchString(repo.Name) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("install repository name should consist of letters, digits, '_', '.', '@' and '-': %q", repo.Name))
+	}
+
+	if repo.URL == "" {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("install repository %q should have url set", repo.Name))
+	}
+
+	if strings.TrimSpace(repo.Key) == "" {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("install repository %q should have signing key set", repo.Name))
+	}
+
+	return multiErr.ErrorOrNil()
+}
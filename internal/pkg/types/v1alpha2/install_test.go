@@ -0,0 +1,191 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestInstall(t *testing.T) {
+	for _, test := range []struct {
+		name            string
+		yaml            string
+		packageManager  v1alpha2.PackageManager
+		expectedInstall v1alpha2.Install
+		expectedError   string
+	}{
+		{
+			name:            "list",
+			yaml:            "install:\n  - make\n  - bash=5.2.37-r0\n",
+			packageManager:  v1alpha2.PackageManagerAPK,
+			expectedInstall: v1alpha2.Install{Packages: []string{"make", "bash=5.2.37-r0"}},
+		},
+		{
+			name: "repositories",
+			yaml: `install:
+  packages:
+    - docker-ce
+  repositories:
+    - name: docker
+      url: https://download.docker.com/linux/debian
+      suite: bookworm
+      components: [stable]
+      key: KEY
+`,
+			packageManager: v1alpha2.PackageManagerAPT,
+			expectedInstall: v1alpha2.Install{
+				Packages: []string{"docker-ce"},
+				Repositories: []v1alpha2.Repository{
+					{
+						Name:       "docker",
+						URL:        "https://download.docker.com/linux/debian",
+						Suite:      "bookworm",
+						Components: []string{"stable"},
+						Key:        "KEY",
+					},
+				},
+			},
+		},
+		{
+			name:            "no package manager",
+			yaml:            "install:\n  - make\n",
+			expectedInstall: v1alpha2.Install{Packages: []string{"make"}},
+			expectedError:   "install is not supported, variant has no package manager",
+		},
+		{
+			name:            "invalid pin",
+			yaml:            "install:\n  - make=\n",
+			packageManager:  v1alpha2.PackageManagerAPK,
+			expectedInstall: v1alpha2.Install{Packages: []string{"make="}},
+			expectedError:   `install package should be in name[=version] format: "make="`,
+		},
+		{
+			name: "invalid repository",
+			yaml: `install:
+  packages:
+    - tool
+  repositories:
+    - name: extra
+      url: https://example.com/alpine
+`,
+			packageManager: v1alpha2.PackageManagerAPK,
+			expectedInstall: v1alpha2.Install{
+				Packages:     []string{"tool"},
+				Repositories: []v1alpha2.Repository{{Name: "extra", URL: "https://example.com/alpine"}},
+			},
+			expectedError: `install repository "extra" should have signing key set`,
+		},
+		{
+			name: "apt suite",
+			yaml: `install:
+  packages:
+    - tool
+  repositories:
+    - name: extra
+      url: https://example.com/debian
+      key: KEY
+`,
+			packageManager: v1alpha2.PackageManagerAPT,
+			expectedInstall: v1alpha2.Install{
+				Packages:     []string{"tool"},
+				Repositories: []v1alpha2.Repository{{Name: "extra", URL: "https://example.com/debian", Key: "KEY"}},
+			},
+			expectedError: `install repository "extra" should have suite set for apt`,
+		},
+		{
+			name: "custom repositories",
+			yaml: `install:
+  packages:
+    - tool
+  repositories:
+    - name: extra
+      url: https://example.com/packages
+      key: KEY
+`,
+			packageManager: v1alpha2.PackageManagerCustom,
+			expectedInstall: v1alpha2.Install{
+				Packages:     []string{"tool"},
+				Repositories: []v1alpha2.Repository{{Name: "extra", URL: "https://example.com/packages", Key: "KEY"}},
+			},
+			expectedError: `install repositories are not supported for custom package manager`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var pkg v1alpha2.Pkg
+
+			require.NoError(t, yaml.Unmarshal([]byte(test.yaml), &pkg))
+			assert.Equal(t, test.expectedInstall, pkg.Install)
+
+			out, err := yaml.Marshal(pkg)
+			require.NoError(t, err)
+
+			var roundtrip v1alpha2.Pkg
+
+			require.NoError(t, yaml.Unmarshal(out, &roundtrip))
+			assert.Equal(t, pkg.Install, roundtrip.Install)
+
+			err = pkg.Install.Validate()
+			if err == nil {
+				err = pkg.Install.ValidateFor(test.packageManager)
+			}
+
+			if test.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInstallMarshalJSON(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		install  v1alpha2.Install
+		expected string
+	}{
+		{
+			name:     "empty",
+			expected: `null`,
+		},
+		{
+			name:     "list",
+			install:  v1alpha2.Install{Packages: []string{"make"}},
+			expected: `["make"]`,
+		},
+		{
+			name: "repositories",
+			install: v1alpha2.Install{
+				Packages:     []string{"tool"},
+				Repositories: []v1alpha2.Repository{{Name: "extra", URL: "https://example.com/alpine", Key: "KEY"}},
+			},
+			expected: `{"packages":["tool"],"repositories":[{"name":"extra","url":"https://example.com/alpine","key":"KEY"}]}`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := json.Marshal(test.install)
+			require.NoError(t, err)
+			assert.JSONEq(t, test.expected, string(out))
+		})
+	}
+}
+
+func TestSplitPackage(t *testing.T) {
+	name, version := v1alpha2.SplitPackage("bash=5.2.37-r0")
+	assert.Equal(t, "bash", name)
+	assert.Equal(t, "5.2.37-r0", version)
+
+	name, version = v1alpha2.SplitPackage("make")
+	assert.Equal(t, "make", name)
+	assert.Empty(t, version)
+}
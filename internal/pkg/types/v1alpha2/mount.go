@@ -0,0 +1,243 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/docker/go-units"
+	"github.com/hashicorp/go-multierror"
+)
+
+// MountType is a kind of the step mount.
+//
+//nolint:recvcheck
+type MountType int
+
+const (
+	// MountTypeUnset is a mount type that is not set.
+	MountTypeUnset MountType = iota
+	// MountTypeTmpfs mounts an empty tmpfs, contents are discarded after each instruction.
+	MountTypeTmpfs
+	// MountTypeBind mounts a read-only directory from another stage or image.
+	MountTypeBind
+	// MountTypeCache mounts a persistent cache directory shared between builds.
+	MountTypeCache
+)
+
+func (t MountType) String() string {
+	return []string{"unset", "tmpfs", "bind", "cache"}[t]
+}
+
+// UnmarshalYAML implements yaml.Unmarshaller interface.
+func (t *MountType) UnmarshalYAML(unmarshal func(any) error) error {
+	var aux string
+
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	var val MountType
+
+	switch aux {
+	case MountTypeTmpfs.String():
+		val = MountTypeTmpfs
+	case MountTypeBind.String():
+		val = MountTypeBind
+	case MountTypeCache.String():
+		val = MountTypeCache
+	default:
+		return fmt.Errorf("unknown mount type %q", aux)
+	}
+
+	*t = val
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaller interface.
+func (t MountType) MarshalYAML() (any, error) {
+	return t.String(), nil
+}
+
+// CacheSharing is a sharing mode of the cache mount.
+//
+//nolint:recvcheck
+type CacheSharing int
+
+const (
+	// CacheSharingShared allows concurrent access to the cache from multiple builds.
+	CacheSharingShared CacheSharing = iota
+	// CacheSharingPrivate creates a new cache directory if there are concurrent builds.
+	CacheSharingPrivate
+	// CacheSharingLocked waits for other builds to release the cache.
+	CacheSharingLocked
+)
+
+func (s CacheSharing) String() string {
+	return []string{"shared", "private", "locked"}[s]
+}
+
+// UnmarshalYAML implements yaml.Unmarshaller interface.
+func (s *CacheSharing) UnmarshalYAML(unmarshal func(any) error) error {
+	var aux string
+
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	var val CacheSharing
+
+	switch aux {
+	case CacheSharingShared.String():
+		val = CacheSharingShared
+	case CacheSharingPrivate.String():
+		val = CacheSharingPrivate
+	case CacheSharingLocked.String():
+		val = CacheSharingLocked
+	default:
+		return fmt.Errorf("unknown cache sharing mode %q", aux)
+	}
+
+	*s = val
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaller interface.
+func (s CacheSharing) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// Mounts is a list of Mount.
+type Mounts []Mount
+
+// Validate mounts.
+func (mounts Mounts) Validate() error {
+	var multiErr *multierror.Error
+
+	targets := map[string]struct{}{}
+
+	for _, mount := range mounts {
+		multiErr = multierror.Append(multiErr, mount.Validate())
+
+		if _, duplicate := targets[mount.Target]; duplicate {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("mount target %q is used multiple times", mount.Target))
+		}
+
+		targets[mount.Target] = struct{}{}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Mount is an additional mount for the step instructions.
+type Mount struct {
+	// Target is the path to mount at.
+	Target string `yaml:"target,omitempty"`
+	// Size limits the size of tmpfs mount (e.g. 512m, 2g).
+	Size string `yaml:"size,omitempty"`
+	// Stage or Image to bind mount from (read-only).
+	Stage string `yaml:"stage,omitempty"`
+	Image string `yaml:"image,omitempty"`
+	// Source is the path in the Stage or Image to bind mount, defaults to `/`.
+	Source string `yaml:"source,omitempty"`
+	// Platform overrides the platform of the Stage or Image.
+	Platform string `yaml:"platform,omitempty"`
+	// ID of the cache mount, defaults to the Target.
+	ID      string       `yaml:"id,omitempty"`
+	Type    MountType    `yaml:"type,omitempty"`
+	Sharing CacheSharing `yaml:"sharing,omitempty"`
+}
+
+// SizeBytes returns parsed tmpfs size, or zero if not set.
+func (mount *Mount) SizeBytes() int64 {
+	if mount.Size == "" {
+		return 0
+	}
+
+	size, err := units.RAMInBytes(mount.Size)
+	if err != nil {
+		return 0
+	}
+
+	return size
+}
+
+// GetSource returns bind mount source path.
+func (mount *Mount) GetSource() string {
+	if mount.Source != "" {
+		return mount.Source
+	}
+
+	return "/"
+}
+
+// GetID returns cache mount ID.
+func (mount *Mount) GetID() string {
+	if mount.ID != "" {
+		return mount.ID
+	}
+
+	return mount.Target
+}
+
+// Dependency returns bind mount source as a dependency.
+func (mount *Mount) Dependency() Dependency {
+	return Dependency{
+		Stage:    mount.Stage,
+		Image:    mount.Image,
+		Platform: mount.Platform,
+	}
+}
+
+// Validate the mount.
+//
+//nolint:gocyclo,cyclop
+func (mount *Mount) Validate() error {
+	var multiErr *multierror.Error
+
+	if mount.Target == "" {
+		multiErr = multierror.Append(multiErr, errors.New("mount.target can't be empty"))
+	} else if !filepath.IsAbs(mount.Target) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("mount.target should be absolute: %q", mount.Target))
+	}
+
+	isBind := mount.Stage != "" || mount.Image != "" || mount.Source != "" || mount.Platform != ""
+	isCache := mount.ID != "" || mount.Sharing != CacheSharingShared
+
+	switch mount.Type {
+	case MountTypeUnset:
+		multiErr = multierror.Append(multiErr, fmt.Errorf("mount %q type should be set", mount.Target))
+	case MountTypeTmpfs:
+		if isBind || isCache {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("tmpfs mount %q supports only size", mount.Target))
+		}
+
+		if mount.Size != "" {
+			if _, err := units.RAMInBytes(mount.Size); err != nil {
+				multiErr = multierror.Append(multiErr, fmt.Errorf("tmpfs mount %q size is invalid: %w", mount.Target, err))
+			}
+		}
+	case MountTypeBind:
+		if mount.Size != "" || isCache {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("bind mount %q supports only stage, image, source and platform", mount.Target))
+		}
+
+		dep := mount.Dependency()
+
+		if err := dep.Validate(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("bind mount %q: %w", mount.Target, err))
+		}
+	case MountTypeCache:
+		if mount.Size != "" || isBind {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("cache mount %q supports only id and sharing", mount.Target))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
@@ -0,0 +1,110 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestMountsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		mounts        v1alpha2.Mounts
+		expectedError string
+	}{
+		{
+			name: "valid",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeTmpfs, Target: "/tmp", Size: "512m"},
+				{Type: v1alpha2.MountTypeBind, Target: "/toolchain", Stage: "toolchain", Source: "/toolchain"},
+				{Type: v1alpha2.MountTypeCache, Target: "/root/.cache", Sharing: v1alpha2.CacheSharingLocked},
+			},
+		},
+		{
+			name: "duplicate target",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeTmpfs, Target: "/tmp"},
+				{Type: v1alpha2.MountTypeCache, Target: "/tmp"},
+			},
+			expectedError: `mount target "/tmp" is used multiple times`,
+		},
+		{
+			name: "relative target",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeTmpfs, Target: "tmp"},
+			},
+			expectedError: `mount.target should be absolute: "tmp"`,
+		},
+		{
+			name: "no type",
+			mounts: v1alpha2.Mounts{
+				{Target: "/tmp"},
+			},
+			expectedError: `mount "/tmp" type should be set`,
+		},
+		{
+			name: "invalid size",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeTmpfs, Target: "/tmp", Size: "lots"},
+			},
+			expectedError: `tmpfs mount "/tmp" size is invalid`,
+		},
+		{
+			name: "bind without source",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeBind, Target: "/toolchain"},
+			},
+			expectedError: `bind mount "/toolchain"`,
+		},
+		{
+			name: "bind with size",
+			mounts: v1alpha2.Mounts{
+				{Type: v1alpha2.MountTypeBind, Target: "/toolchain", Stage: "toolchain", Size: "1g"},
+			},
+			expectedError: `bind mount "/toolchain" supports only stage, image, source and platform`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.mounts.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestMountUnmarshal(t *testing.T) {
+	var mounts v1alpha2.Mounts
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+- type: tmpfs
+  target: /tmp
+  size: 1g
+- type: cache
+  target: /root/.cache
+  sharing: private
+`), &mounts))
+
+	require.Len(t, mounts, 2)
+	assert.Equal(t, v1alpha2.MountTypeTmpfs, mounts[0].Type)
+	assert.EqualValues(t, 1<<30, mounts[0].SizeBytes())
+	assert.Equal(t, v1alpha2.MountTypeCache, mounts[1].Type)
+	assert.Equal(t, v1alpha2.CacheSharingPrivate, mounts[1].Sharing)
+	assert.Equal(t, "/root/.cache", mounts[1].GetID())
+
+	require.Error(t, yaml.Unmarshal([]byte(`- type: overlay`), &mounts))
+}
@@ -0,0 +1,76 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// OutputSeparator separates package name and output name in the stage reference (e.g. gcc:dev).
+const OutputSeparator = ":"
+
+var outputNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// SplitStage splits stage reference into the package name and the output name.
+//
+// Output name is empty for the default output of the package.
+func SplitStage(stage string) (name, output string) {
+	name, output, _ = strings.Cut(stage, OutputSeparator)
+
+	return name, output
+}
+
+// Outputs is a list of Output.
+type Outputs []Output
+
+// Validate outputs.
+func (outputs Outputs) Validate() error {
+	var multiErr *multierror.Error
+
+	names := map[string]struct{}{}
+
+	for _, output := range outputs {
+		multiErr = multierror.Append(multiErr, output.Validate())
+
+		if _, duplicate := names[output.Name]; duplicate {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("output %q is defined multiple times", output.Name))
+		}
+
+		names[output.Name] = struct{}{}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Output is an additional named output of the package (subpackage).
+//
+// Outputs share the build with the package, and only differ in the finalize instructions.
+type Output struct {
+	Name     string     `yaml:"name,omitempty"`
+	Finalize []Finalize `yaml:"finalize,omitempty"`
+}
+
+// Validate the output.
+func (output *Output) Validate() error {
+	var multiErr *multierror.Error
+
+	if !outputNameRe.MatchString(output.Name) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("output name %q is invalid, it should match %s", output.Name, outputNameRe))
+	}
+
+	if len(output.Finalize) == 0 {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("output %q has no finalize steps", output.Name))
+	}
+
+	for _, fin := range output.Finalize {
+		multiErr = multierror.Append(multiErr, fin.Validate())
+	}
+
+	return multiErr.ErrorOrNil()
+}
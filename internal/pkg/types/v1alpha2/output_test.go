@@ -0,0 +1,103 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestSplitStage(t *testing.T) {
+	name, output := v1alpha2.SplitStage("gcc")
+	assert.Equal(t, "gcc", name)
+	assert.Empty(t, output)
+
+	name, output = v1alpha2.SplitStage("gcc:dev")
+	assert.Equal(t, "gcc", name)
+	assert.Equal(t, "dev", output)
+}
+
+func TestOutputsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		outputs       v1alpha2.Outputs
+		expectedError string
+	}{
+		{
+			name: "valid",
+			outputs: v1alpha2.Outputs{
+				{Name: "dev", Finalize: []v1alpha2.Finalize{{From: "/rootfs/usr/include", To: "/usr/include"}}},
+				{Name: "runtime", Finalize: []v1alpha2.Finalize{{From: "/rootfs/usr/lib", To: "/usr/lib"}}},
+			},
+		},
+		{
+			name: "duplicate",
+			outputs: v1alpha2.Outputs{
+				{Name: "dev", Finalize: []v1alpha2.Finalize{{From: "/rootfs"}}},
+				{Name: "dev", Finalize: []v1alpha2.Finalize{{From: "/rootfs"}}},
+			},
+			expectedError: `output "dev" is defined multiple times`,
+		},
+		{
+			name: "invalid name",
+			outputs: v1alpha2.Outputs{
+				{Name: "gcc:dev", Finalize: []v1alpha2.Finalize{{From: "/rootfs"}}},
+			},
+			expectedError: `output name "gcc:dev" is invalid`,
+		},
+		{
+			name: "no finalize",
+			outputs: v1alpha2.Outputs{
+				{Name: "dev"},
+			},
+			expectedError: `output "dev" has no finalize steps`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.outputs.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestPkgGetFinalize(t *testing.T) {
+	pkg := v1alpha2.Pkg{
+		Finalize: []v1alpha2.Finalize{{From: "/rootfs"}},
+		Outputs: v1alpha2.Outputs{
+			{Name: "dev", Finalize: []v1alpha2.Finalize{{From: "/rootfs/usr/include"}}},
+		},
+	}
+
+	finalize, ok := pkg.GetFinalize("")
+	require.True(t, ok)
+	assert.Equal(t, "/rootfs", finalize[0].From)
+
+	finalize, ok = pkg.GetFinalize("dev")
+	require.True(t, ok)
+	assert.Equal(t, "/rootfs/usr/include", finalize[0].From)
+
+	_, ok = pkg.GetFinalize("runtime")
+	assert.False(t, ok)
+}
+
+func TestDependencyValidateStage(t *testing.T) {
+	require.NoError(t, (&v1alpha2.Dependency{Stage: "gcc:dev"}).Validate())
+
+	err := (&v1alpha2.Dependency{Stage: "gcc:"}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `dependency stage should be in name[:output] format: "gcc:"`)
+}
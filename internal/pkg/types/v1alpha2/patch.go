@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+)
+
+// Patches is a list of Patch applied in order.
+type Patches []Patch
+
+// Validate patches.
+func (patches Patches) Validate() error {
+	var multiErr *multierror.Error
+
+	for _, patch := range patches {
+		multiErr = multierror.Append(multiErr, patch.Validate())
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Patch is a patch file from the package directory applied to the step sources.
+type Patch struct {
+	// Strip is the number of leading path components to strip, defaults to 1.
+	Strip *int `yaml:"strip,omitempty"`
+	// File is the path to the patch file relative to the package directory.
+	File string `yaml:"file,omitempty"`
+	// Directory is the path relative to the step temporary directory to apply the patch in.
+	Directory string `yaml:"directory,omitempty"`
+}
+
+// GetStrip returns the strip level.
+func (patch *Patch) GetStrip() int {
+	if patch.Strip == nil {
+		return 1
+	}
+
+	return *patch.Strip
+}
+
+// Validate the patch.
+func (patch *Patch) Validate() error {
+	var multiErr *multierror.Error
+
+	switch {
+	case patch.File == "":
+		multiErr = multierror.Append(multiErr, errors.New("patch.file can't be empty"))
+	case !filepath.IsLocal(patch.File):
+		multiErr = multierror.Append(multiErr, fmt.Errorf("patch.file should be a relative path within the package directory: %q", patch.File))
+	case !slices.ContainsFunc([]string{constants.PatchExt, constants.DiffExt}, func(ext string) bool { return strings.HasSuffix(patch.File, ext) }):
+		multiErr = multierror.Append(multiErr, fmt.Errorf("patch.file should have %q or %q extension: %q", constants.PatchExt, constants.DiffExt, patch.File))
+	}
+
+	if patch.Directory != "" && !filepath.IsLocal(patch.Directory) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("patch.directory should be a relative path within the step directory: %q", patch.Directory))
+	}
+
+	if patch.GetStrip() < 0 {
+		multiErr = multierror.Append(multiErr, errors.New("patch.strip can't be negative"))
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// PatchFile is a patch file from the package directory referenced by the steps.
+type PatchFile struct {
+	Path   string
+	SHA256 string
+}
+
+// AttachPatchFile attaches a patch file referenced by the steps to the package.
+func (p *Pkg) AttachPatchFile(path string, content []byte) {
+	sum := sha256.Sum256(content)
+
+	p.PatchFiles = append(p.PatchFiles, PatchFile{
+		Path:   path,
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+}
+
+// GetPatchFiles returns a list of patch files referenced by the package steps.
+func (p *Pkg) GetPatchFiles() []PatchFile {
+	return p.PatchFiles
+}
@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestPatchValidate(t *testing.T) {
+	zero := 0
+
+	for _, test := range []struct {
+		name          string
+		patch         v1alpha2.Patch
+		expectedStrip int
+		expectedError string
+	}{
+		{
+			name:          "default strip",
+			patch:         v1alpha2.Patch{File: "patches/musl-fix.patch"},
+			expectedStrip: 1,
+		},
+		{
+			name:          "strip zero",
+			patch:         v1alpha2.Patch{File: "patches/musl-fix.diff", Strip: &zero},
+			expectedStrip: 0,
+		},
+		{
+			name:          "outside of package",
+			patch:         v1alpha2.Patch{File: "../other/musl-fix.patch"},
+			expectedStrip: 1,
+			expectedError: `patch.file should be a relative path within the package directory: "../other/musl-fix.patch"`,
+		},
+		{
+			name:          "extension",
+			patch:         v1alpha2.Patch{File: "patches/musl-fix.txt"},
+			expectedStrip: 1,
+			expectedError: `patch.file should have ".patch" or ".diff" extension: "patches/musl-fix.txt"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expectedStrip, test.patch.GetStrip())
+
+			err := test.patch.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
@@ -7,10 +7,11 @@ package v1alpha2
 import (
 	"bytes"
 	"errors"
-	"text/template"
+	"fmt"
+	"net/url"
 
-	"github.com/Masterminds/sprig/v3"
 	"github.com/hashicorp/go-multierror"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"go.yaml.in/yaml/v4"
 
 	"github.com/siderolabs/bldr/internal/pkg/constants"
@@ -20,49 +21,73 @@ import (
 // Pkg represents build instructions for a single package.
 type Pkg struct {
 	TemplatedFiles []TemplatedFile `yaml:"-"`
+	PatchFiles     []PatchFile     `yaml:"-"`
 	Context        types.Variables `yaml:"-"`
 	Name           string          `yaml:"name,omitempty"`
+	Abstract       bool            `yaml:"abstract,omitempty"`
+	Extends        string          `yaml:"extends,omitempty"`
+	Version        string          `yaml:"version,omitempty"`
+	License        string          `yaml:"license,omitempty"`
+	Homepage       string          `yaml:"homepage,omitempty"`
+	Description    string          `yaml:"description,omitempty"`
 	Shell          Shell           `yaml:"shell,omitempty"`
+	Env            Environment     `yaml:"env,omitempty"`
 	BaseDir        string          `yaml:"-"`
 	FileName       string          `yaml:"-"`
 	BuildPlatform  string          `yaml:"buildPlatform,omitempty"`
+	Platforms      Platforms       `yaml:"platforms,omitempty"`
 	Install        Install         `yaml:"install,omitempty"`
 	Dependencies   Dependencies    `yaml:"dependencies,omitempty"`
 	Steps          Steps           `yaml:"steps,omitempty"`
 	Finalize       []Finalize      `yaml:"finalize,omitempty"`
+	Outputs        Outputs         `yaml:"outputs,omitempty"`
+	Image          *Image          `yaml:"image,omitempty"`
 	Variant        Variant         `yaml:"variant,omitempty"`
 }
 
 // NewPkg loads Pkg structure from file.
 func NewPkg(baseDir, fileName string, contents []byte, vars types.Variables) (*Pkg, error) {
+	p, err := ParsePkg(baseDir, fileName, contents, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	p.SetDefaults()
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ParsePkg parses Pkg structure from file without setting defaults and validation.
+//
+// Package might extend a template, so it is validated after resolving `extends` (see ResolveExtends).
+func ParsePkg(baseDir, fileName string, contents []byte, vars types.Variables) (*Pkg, error) {
 	p := &Pkg{
 		BaseDir:  baseDir,
 		FileName: fileName,
-		Shell:    "/bin/sh",
 		Context:  vars.Copy(),
 	}
 
-	tmpl, err := template.New(constants.PkgYaml).
-		Funcs(sprig.HermeticTxtFuncMap()).
-		Parse(string(contents))
+	rendered, err := vars.Render(constants.PkgYaml, contents)
 	if err != nil {
 		return nil, err
 	}
 
-	var buf bytes.Buffer
-	if err = tmpl.Execute(&buf, vars); err != nil {
+	if err := yaml.NewDecoder(bytes.NewReader(rendered)).Decode(p); err != nil {
 		return nil, err
 	}
 
-	if err := yaml.NewDecoder(&buf).Decode(p); err != nil {
-		return nil, err
-	}
+	return p, nil
+}
 
-	if err := p.Validate(); err != nil {
-		return nil, err
+// SetDefaults sets default values of the unset fields.
+func (p *Pkg) SetDefaults() {
+	if p.Shell == "" {
+		p.Shell = "/bin/sh"
 	}
-
-	return p, nil
 }
 
 // Validate the Pkg.
@@ -73,15 +98,84 @@ func (p *Pkg) Validate() error {
 		multiErr = multierror.Append(multiErr, errors.New("package name can't be empty"))
 	}
 
+	if p.Homepage != "" {
+		if u, err := url.Parse(p.Homepage); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("package homepage should be an http(s) URL: %q", p.Homepage))
+		}
+	}
+
 	if p.Variant == Unset {
 		multiErr = multierror.Append(multiErr, errors.New("variant should be set"))
 	}
 
-	if len(p.Steps) > 0 && len(p.Finalize) == 0 {
+	if len(p.Steps) > 0 && len(p.Finalize) == 0 && len(p.Outputs) == 0 {
 		multiErr = multierror.Append(multiErr, errors.New("finalize steps are missing, this is going to lead to empty build"))
 	}
 
-	multiErr = multierror.Append(multiErr, p.Steps.Validate(), p.Dependencies.Validate())
+	multiErr = multierror.Append(multiErr, p.Install.Validate(), p.Steps.Validate(), p.Dependencies.Validate(), p.Platforms.Validate(), p.Outputs.Validate(), p.Image.Validate())
+
+	// secret values should never be set via the environment, as it ends up in the build definition:
+	// package env is applied to every step, and step env persists into the following steps
+	stepEnv := map[string]int{}
+
+	for i, step := range p.Steps {
+		for key := range step.Env {
+			if _, ok := stepEnv[key]; !ok {
+				stepEnv[key] = i
+			}
+		}
+
+		for _, secret := range step.Secrets {
+			if secret.Env == "" {
+				continue
+			}
+
+			if _, ok := p.Env[secret.Env]; ok {
+				multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q env %q conflicts with the package env", secret.ID, secret.Env))
+			}
+
+			if j, ok := stepEnv[secret.Env]; ok {
+				multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q env %q conflicts with the env of step %d", secret.ID, secret.Env, j))
+			}
+		}
+	}
+
+	for _, fin := range p.Finalize {
+		multiErr = multierror.Append(multiErr, fin.Validate())
+	}
 
 	return multiErr.ErrorOrNil()
 }
+
+// MetadataLabels returns OCI image labels for the package metadata.
+func (p *Pkg) MetadataLabels() map[string]string {
+	labels := map[string]string{}
+
+	for key, value := range map[string]string{
+		specs.AnnotationVersion:     p.Version,
+		specs.AnnotationLicenses:    p.License,
+		specs.AnnotationURL:         p.Homepage,
+		specs.AnnotationDescription: p.Description,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+
+	return labels
+}
+
+// GetFinalize returns finalize instructions of the output, empty output name is the package itself.
+func (p *Pkg) GetFinalize(output string) ([]Finalize, bool) {
+	if output == "" {
+		return p.Finalize, true
+	}
+
+	for _, out := range p.Outputs {
+		if out.Name == output {
+			return out.Finalize, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,41 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestPkgMetadata(t *testing.T) {
+	pkg, err := v1alpha2.NewPkg("runc", "pkg.yaml", []byte(`name: runc
+variant: scratch
+version: "{{ .RUNC_VERSION }}"
+license: Apache-2.0
+homepage: https://github.com/opencontainers/runc
+`), types.Variables{"RUNC_VERSION": "1.3.0"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.3.0", pkg.Version)
+	assert.Equal(t, map[string]string{
+		"org.opencontainers.image.version":  "1.3.0",
+		"org.opencontainers.image.licenses": "Apache-2.0",
+		"org.opencontainers.image.url":      "https://github.com/opencontainers/runc",
+	}, pkg.MetadataLabels())
+}
+
+func TestPkgMetadataValidate(t *testing.T) {
+	_, err := v1alpha2.NewPkg("runc", "pkg.yaml", []byte(`name: runc
+variant: scratch
+homepage: github.com/opencontainers/runc
+`), types.Variables{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `package homepage should be an http(s) URL: "github.com/opencontainers/runc"`)
+}
@@ -6,17 +6,24 @@ package v1alpha2
 
 import (
 	"fmt"
+	"maps"
 
+	"github.com/hashicorp/go-multierror"
 	"go.yaml.in/yaml/v4"
 
+	"github.com/siderolabs/bldr/internal/pkg/environment"
 	"github.com/siderolabs/bldr/internal/pkg/types"
 )
 
 // Pkgfile describes structure of 'Pkgfile'.
 type Pkgfile struct {
-	Vars   types.Variables   `yaml:"vars,omitempty"`
-	Labels map[string]string `yaml:"labels,omitempty"`
-	Format string            `yaml:"format"`
+	Vars      types.Variables               `yaml:"vars,omitempty"`
+	Variables VariableDeclarations          `yaml:"variables,omitempty"`
+	Defaults  types.Variables               `yaml:"defaults,omitempty"`
+	Platforms map[string]PlatformDefinition `yaml:"platforms,omitempty"`
+	Labels    map[string]string             `yaml:"labels,omitempty"`
+	Variants  map[Variant]VariantDefinition `yaml:"variants,omitempty"`
+	Format    string                        `yaml:"format"`
 }
 
 // NewPkgfile loads Pkgfile from `[]byte` contents.
@@ -33,5 +40,100 @@ func NewPkgfile(contents []byte) (*Pkgfile, error) {
 		return nil, fmt.Errorf("unsupported format: %q, supported formats: %q", pkgfile.Format, []string{"v1alpha2"})
 	}
 
+	if err := pkgfile.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &pkgfile, nil
 }
+
+// Validate the Pkgfile.
+func (pkgfile *Pkgfile) Validate() error {
+	var multiErr *multierror.Error
+
+	for name, def := range pkgfile.Variants {
+		if err := name.Validate(); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+
+		if err := def.Validate(); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variant %q: %w", name, err))
+		}
+	}
+
+	if err := pkgfile.Variables.Validate(); err != nil {
+		multiErr = multierror.Append(multiErr, err)
+	}
+
+	if err := validateDefaults(pkgfile.Defaults); err != nil {
+		multiErr = multierror.Append(multiErr, err)
+	}
+
+	for id, def := range pkgfile.Platforms {
+		if err := def.Validate(id); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// CheckVariables checks the package context against the variables declared in the Pkgfile.
+func (pkgfile *Pkgfile) CheckVariables(vars types.Variables) error {
+	if pkgfile == nil {
+		return nil
+	}
+
+	return pkgfile.Variables.Check(vars)
+}
+
+// GetVariants returns built-in variants merged with the variants defined in the Pkgfile.
+//
+// Pkgfile might override built-in variants, e.g. to pin a different Alpine image.
+func (pkgfile *Pkgfile) GetVariants() map[Variant]VariantDefinition {
+	variants := BuiltinVariants()
+
+	if pkgfile != nil {
+		maps.Copy(variants, pkgfile.Variants)
+	}
+
+	return variants
+}
+
+// ConfigureOptions applies the platform definitions and default variables from the Pkgfile to the options,
+// and resolves build and target platforms.
+//
+// Pkgfile is optional, without the Pkgfile only the built-in platforms are available.
+func (pkgfile *Pkgfile) ConfigureOptions(options *environment.Options) error {
+	if pkgfile != nil {
+		options.Defaults = pkgfile.Defaults.Copy()
+		options.Platforms = make(map[string]environment.Platform, len(pkgfile.Platforms))
+
+		for id, def := range pkgfile.Platforms {
+			platform, err := def.Platform(id)
+			if err != nil {
+				return err
+			}
+
+			options.Platforms[id] = platform
+		}
+	}
+
+	return options.ResolvePlatforms()
+}
+
+func validateDefaults(defaults types.Variables) error {
+	var multiErr *multierror.Error
+
+	for name, value := range defaults {
+		if !envNameRe.MatchString(name) {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("default variable %q should be a valid environment variable name", name))
+		}
+
+		if _, ok := value.(string); !ok {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("default variable %q should be a string", name))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestPkgfileVariants(t *testing.T) {
+	pkgfile, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+variants:
+  debian:
+    image: docker.io/library/debian:bookworm-slim
+    packageManager: apt
+  alpine:
+    image: docker.io/library/alpine:3.22
+  wolfi:
+    image: cgr.dev/chainguard/wolfi-base
+    packageManager: custom
+    install:
+      - apk
+      - add
+      - --no-cache
+`))
+	require.NoError(t, err)
+
+	variants := pkgfile.GetVariants()
+
+	assert.Equal(t, "docker.io/library/debian:bookworm-slim", variants["debian"].Image)
+	assert.Equal(t, v1alpha2.PackageManagerAPT, variants["debian"].PackageManager)
+	assert.Equal(t, "docker.io/library/alpine:3.22", variants[v1alpha2.Alpine].Image)
+	assert.Empty(t, variants[v1alpha2.Scratch].Image)
+	assert.Equal(t, v1alpha2.PackageManagerCustom, variants["wolfi"].PackageManager)
+	assert.Equal(t, []string{"apk", "add", "--no-cache"}, variants["wolfi"].Install)
+
+	// Pkgfile is optional
+	assert.Equal(t, constants.DefaultBaseImage, (*v1alpha2.Pkgfile)(nil).GetVariants()[v1alpha2.Alpine].Image)
+	assert.Equal(t, v1alpha2.PackageManagerAPK, (*v1alpha2.Pkgfile)(nil).GetVariants()[v1alpha2.Alpine].PackageManager)
+}
+
+func TestPkgfileVariantsValidate(t *testing.T) {
+	_, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+variants:
+  Debian:
+    image: docker.io/library/debian:bookworm-slim
+  empty:
+    setup:
+      - apt-get update
+  zypper:
+    image: registry.opensuse.org/opensuse/tumbleweed
+    packageManager: zypper
+  noinstall:
+    image: registry.opensuse.org/opensuse/tumbleweed
+    packageManager: custom
+  noncustom:
+    image: docker.io/library/debian:bookworm-slim
+    packageManager: apt
+    install:
+      - apt-get
+      - install
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `variant name "Debian" is invalid`)
+	assert.Contains(t, err.Error(), `variant "empty": variant without image can't have setup commands or package manager`)
+	assert.Contains(t, err.Error(), `variant "zypper": unsupported package manager "zypper"`)
+	assert.Contains(t, err.Error(), `variant "noinstall": install command should be set if and only if package manager is custom`)
+	assert.Contains(t, err.Error(), `variant "noncustom": install command should be set if and only if package manager is custom`)
+}
+
+func TestPkgfilePlatforms(t *testing.T) {
+	pkgfile, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+defaults:
+  VENDOR: acme
+platforms:
+  linux/amd64:
+    target: x86_64-acme-linux-musl
+  linux/mips64le:
+    arch: mips64el
+    target: mips64el-acme-linux-musl
+    build: mips64el-linux-musl
+    host: mips64el-linux-musl
+    cflags: -O2
+`))
+	require.NoError(t, err)
+
+	options := environment.Options{
+		BuildPlatform:  environment.LinuxAmd64,
+		TargetPlatform: environment.LinuxAmd64,
+	}
+
+	require.NoError(t, options.TargetPlatform.Set("linux/mips64le"))
+	require.NoError(t, pkgfile.ConfigureOptions(&options))
+
+	assert.Equal(t, "x86_64-acme-linux-musl", options.BuildPlatform.Target)
+	assert.Equal(t, environment.LinuxAmd64.CFlags, options.BuildPlatform.CFlags)
+	assert.Equal(t, "mips64el-acme-linux-musl", options.TargetPlatform.Target)
+	assert.Equal(t, "mips64le", options.TargetPlatform.PlatformSpec.Architecture)
+	assert.Equal(t, "acme", options.GetVariables()["VENDOR"])
+
+	// without the Pkgfile, only built-in platforms are available
+	options = environment.Options{
+		BuildPlatform:  environment.LinuxAmd64,
+		TargetPlatform: options.TargetPlatform,
+	}
+
+	require.Error(t, (*v1alpha2.Pkgfile)(nil).ConfigureOptions(&options))
+}
+
+func TestPkgfilePlatformsValidate(t *testing.T) {
+	_, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+defaults:
+  VENDOR: [acme]
+  1NVALID: value
+platforms:
+  linux/arm:
+    cflags: -O2
+  linux/mips64le:
+    arch: mips64el
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `default variable "VENDOR" should be a string`)
+	assert.Contains(t, err.Error(), `default variable "1NVALID" should be a valid environment variable name`)
+	assert.Contains(t, err.Error(), `platform "linux/arm" should be defined as "linux/arm/v7"`)
+	assert.Contains(t, err.Error(), `platform "linux/mips64le" is not built-in, "target" should be set`)
+}
@@ -0,0 +1,85 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+)
+
+// PlatformDefinition overrides a built-in platform or defines an additional platform in the Pkgfile.
+//
+// Fields which are not set are inherited from the built-in platform.
+type PlatformDefinition struct {
+	Arch   string `yaml:"arch,omitempty"`
+	Target string `yaml:"target,omitempty"`
+	Build  string `yaml:"build,omitempty"`
+	Host   string `yaml:"host,omitempty"`
+	CFlags string `yaml:"cflags,omitempty"`
+}
+
+// Validate the platform definition.
+func (def PlatformDefinition) Validate(id string) error {
+	if normalized := environment.NormalizeID(id); normalized != id {
+		return fmt.Errorf("platform %q should be defined as %q", id, normalized)
+	}
+
+	var base environment.Platform
+
+	if err := base.Set(id); err != nil {
+		return err
+	}
+
+	if _, builtin := environment.Platforms[id]; builtin {
+		return nil
+	}
+
+	var multiErr *multierror.Error
+
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"arch", def.Arch},
+		{"target", def.Target},
+		{"build", def.Build},
+		{"host", def.Host},
+	} {
+		if field.value == "" {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("platform %q is not built-in, %q should be set", id, field.name))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Platform returns the platform definition merged with the built-in platform.
+func (def PlatformDefinition) Platform(id string) (environment.Platform, error) {
+	var platform environment.Platform
+
+	if err := platform.Set(id); err != nil {
+		return platform, err
+	}
+
+	for _, field := range []struct {
+		dst   *string
+		value string
+	}{
+		{&platform.Arch, def.Arch},
+		{&platform.Target, def.Target},
+		{&platform.Build, def.Build},
+		{&platform.Host, def.Host},
+		{&platform.CFlags, def.CFlags},
+	} {
+		if field.value != "" {
+			*field.dst = field.value
+		}
+	}
+
+	return platform, nil
+}
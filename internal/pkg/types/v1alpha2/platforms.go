@@ -0,0 +1,54 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/siderolabs/bldr/internal/pkg/environment"
+)
+
+// Platforms is a list of platforms in os/arch format (e.g. linux/amd64).
+type Platforms []string
+
+// Supports checks whether the platform is in the list, empty list supports any platform.
+//
+// Platforms are compared in the normalized form, so e.g. `linux/arm` matches `linux/arm/v7`.
+func (p Platforms) Supports(platform string) bool {
+	if len(p) == 0 {
+		return true
+	}
+
+	platform = environment.NormalizeID(platform)
+
+	return slices.ContainsFunc(p, func(supported string) bool {
+		return environment.NormalizeID(supported) == platform
+	})
+}
+
+// String returns comma-separated list of platforms.
+func (p Platforms) String() string {
+	return strings.Join(p, ",")
+}
+
+// Validate platforms.
+func (p Platforms) Validate() error {
+	var multiErr *multierror.Error
+
+	for _, platform := range p {
+		if _, err := platforms.Parse(platform); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("invalid platform %q: %w", platform, err))
+		} else if !strings.Contains(platform, "/") {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("platform should be in os/arch format: %q", platform))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
@@ -0,0 +1,31 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestPlatformsSupports(t *testing.T) {
+	assert.True(t, v1alpha2.Platforms(nil).Supports("linux/amd64"))
+	assert.True(t, v1alpha2.Platforms{"linux/amd64", "linux/arm64"}.Supports("linux/arm64"))
+	assert.False(t, v1alpha2.Platforms{"linux/arm64"}.Supports("linux/amd64"))
+	assert.True(t, v1alpha2.Platforms{"linux/arm"}.Supports("linux/arm/v7"))
+	assert.True(t, v1alpha2.Platforms{"linux/arm/v7"}.Supports("linux/arm"))
+	assert.False(t, v1alpha2.Platforms{"linux/arm/v6"}.Supports("linux/arm/v7"))
+}
+
+func TestPlatformsValidate(t *testing.T) {
+	require.NoError(t, v1alpha2.Platforms{"linux/amd64", "linux/arm64"}.Validate())
+
+	err := v1alpha2.Platforms{"linux/amd64", "arm64"}.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `platform should be in os/arch format: "arm64"`)
+}
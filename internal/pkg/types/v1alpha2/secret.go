@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+var (
+	secretIDRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+	envNameRe  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Secrets is a list of Secret.
+type Secrets []Secret
+
+// Validate secrets.
+func (secrets Secrets) Validate() error {
+	var multiErr *multierror.Error
+
+	ids := map[string]struct{}{}
+
+	for _, secret := range secrets {
+		multiErr = multierror.Append(multiErr, secret.Validate())
+
+		if _, duplicate := ids[secret.ID]; duplicate {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q is defined multiple times", secret.ID))
+		}
+
+		ids[secret.ID] = struct{}{}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Secret is a buildkit secret exposed to the step instructions.
+//
+// Secret value is never stored in the build definition, only the secret ID is,
+// the value is provided to the build via `--secret id=<id>,...`.
+type Secret struct {
+	// ID of the secret in the build session.
+	ID string `yaml:"id,omitempty"`
+	// Path to mount the secret file at, defaults to /run/secrets/<id> unless Env is set.
+	Path string `yaml:"path,omitempty"`
+	// Env is the name of the environment variable to expose the secret as.
+	Env string `yaml:"env,omitempty"`
+	// Required fails the build if the secret is not provided.
+	Required bool `yaml:"required,omitempty"`
+}
+
+// GetPath returns the path to mount the secret at, if the secret is exposed as a file.
+func (secret *Secret) GetPath() string {
+	if secret.Path != "" {
+		return secret.Path
+	}
+
+	if secret.Env != "" {
+		return ""
+	}
+
+	return filepath.Join("/run/secrets", secret.ID)
+}
+
+// Validate the secret.
+func (secret *Secret) Validate() error {
+	var multiErr *multierror.Error
+
+	switch {
+	case secret.ID == "":
+		multiErr = multierror.Append(multiErr, errors.New("secret.id can't be empty"))
+	case !secretIDRe.MatchString(secret.ID):
+		multiErr = multierror.Append(multiErr, fmt.Errorf("secret.id should consist of letters, digits, '_', '.' and '-': %q", secret.ID))
+	}
+
+	if secret.Path != "" && secret.Env != "" {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q can't have both path & env set", secret.ID))
+	}
+
+	if secret.Path != "" && !filepath.IsAbs(secret.Path) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q path should be absolute: %q", secret.ID, secret.Path))
+	}
+
+	if secret.Env != "" && !envNameRe.MatchString(secret.Env) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("secret %q env should be a valid environment variable name: %q", secret.ID, secret.Env))
+	}
+
+	return multiErr.ErrorOrNil()
+}
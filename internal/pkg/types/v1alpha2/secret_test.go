@@ -0,0 +1,134 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestStepValidateSecrets(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		step          v1alpha2.Step
+		expectedError string
+	}{
+		{
+			name: "valid",
+			step: v1alpha2.Step{
+				Secrets: v1alpha2.Secrets{
+					{ID: "token"},
+					{ID: "signing-key", Env: "SIGNING_KEY", Required: true},
+				},
+			},
+		},
+		{
+			name: "duplicate",
+			step: v1alpha2.Step{
+				Secrets: v1alpha2.Secrets{
+					{ID: "token"},
+					{ID: "token", Path: "/root/.token"},
+				},
+			},
+			expectedError: `secret "token" is defined multiple times`,
+		},
+		{
+			name: "path and env",
+			step: v1alpha2.Step{
+				Secrets: v1alpha2.Secrets{
+					{ID: "token", Path: "/root/.token", Env: "TOKEN"},
+				},
+			},
+			expectedError: `secret "token" can't have both path & env set`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.step.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestPkgValidateSecretsEnv(t *testing.T) {
+	secretStep := v1alpha2.Step{
+		Secrets: v1alpha2.Secrets{
+			{ID: "signing-key", Env: "SIGNING_KEY"},
+		},
+	}
+
+	envStep := v1alpha2.Step{
+		Env: v1alpha2.Environment{
+			"SIGNING_KEY": "plaintext",
+		},
+	}
+
+	for _, test := range []struct {
+		name          string
+		env           v1alpha2.Environment
+		steps         v1alpha2.Steps
+		expectedError string
+	}{
+		{
+			name: "package env",
+			env: v1alpha2.Environment{
+				"SIGNING_KEY": "plaintext",
+			},
+			steps:         v1alpha2.Steps{secretStep},
+			expectedError: `secret "signing-key" env "SIGNING_KEY" conflicts with the package env`,
+		},
+		{
+			name: "step env",
+			steps: v1alpha2.Steps{
+				{
+					Env:     envStep.Env,
+					Secrets: secretStep.Secrets,
+				},
+			},
+			expectedError: `secret "signing-key" env "SIGNING_KEY" conflicts with the env of step 0`,
+		},
+		{
+			name:          "preceding step env",
+			steps:         v1alpha2.Steps{{}, envStep, secretStep},
+			expectedError: `secret "signing-key" env "SIGNING_KEY" conflicts with the env of step 1`,
+		},
+		{
+			name:  "following step env",
+			steps: v1alpha2.Steps{secretStep, envStep},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pkg := v1alpha2.Pkg{
+				Name:     "pkg",
+				Variant:  v1alpha2.Alpine,
+				Env:      test.env,
+				Steps:    test.steps,
+				Finalize: []v1alpha2.Finalize{{From: "/", To: "/"}},
+			}
+
+			err := pkg.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
@@ -14,10 +14,24 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/hashicorp/go-multierror"
 )
 
+// extractableSuffixes are the suffixes of the archives buildkit can unpack: tar, optionally compressed.
+//
+// Other archives (e.g. zip) would be silently copied as is.
+var extractableSuffixes = []string{
+	".tar",
+	".tar.gz", ".tgz",
+	".tar.bz2", ".tbz2", ".tbz",
+	".tar.xz", ".txz",
+	".tar.zst", ".tzst",
+}
+
 // Sources is a collection of Source.
 type Sources []Source
 
@@ -33,11 +47,34 @@ func (sources Sources) Validate() error {
 }
 
 // Source describe build source to be downloaded.
+//
+// Source is either an HTTP(S) download verified by checksums,
+// or a Git repository checkout pinned to a commit.
+//
+// HTTP(S) download might list mirrors which are tried in order if the URL fails,
+// all of them should serve the same file.
+//
+// Downloaded archive might be extracted into the step temporary directory.
 type Source struct {
-	URL         string `yaml:"url,omitempty"`
-	Destination string `yaml:"destination,omitempty"`
-	SHA256      string `yaml:"sha256,omitempty"`
-	SHA512      string `yaml:"sha512,omitempty"`
+	Git             *GitSource `yaml:"git,omitempty"`
+	URL             string     `yaml:"url,omitempty"`
+	Destination     string     `yaml:"destination,omitempty"`
+	ExtractTo       string     `yaml:"extractTo,omitempty"`
+	SHA256          string     `yaml:"sha256,omitempty"`
+	SHA512          string     `yaml:"sha512,omitempty"`
+	Mirrors         []string   `yaml:"mirrors,omitempty"`
+	StripComponents int        `yaml:"stripComponents,omitempty"`
+	Extract         bool       `yaml:"extract,omitempty"`
+}
+
+// URLs returns the URL followed by the mirrors in the order they should be tried.
+func (source *Source) URLs() []string {
+	return append([]string{source.URL}, source.Mirrors...)
+}
+
+// IsGit checks whether source is a Git repository checkout.
+func (source *Source) IsGit() bool {
+	return source.Git != nil
 }
 
 // ToSHA512Sum returns in format of line expected by 'sha512sum'.
@@ -49,14 +86,38 @@ func (source *Source) ToSHA512Sum() []byte {
 func (source *Source) Validate() error {
 	var multiErr *multierror.Error
 
+	if source.Destination == "" {
+		multiErr = multierror.Append(multiErr, errors.New("source.destination can't be empty"))
+	}
+
+	multiErr = multierror.Append(multiErr, source.validateExtract())
+
+	if source.IsGit() {
+		if source.URL != "" || len(source.Mirrors) > 0 {
+			multiErr = multierror.Append(multiErr, errors.New("source can't have both url & git set"))
+		}
+
+		if source.SHA256 != "" || source.SHA512 != "" {
+			multiErr = multierror.Append(multiErr, errors.New("git source is pinned by commit, sha256/sha512 should not be set"))
+		}
+
+		multiErr = multierror.Append(multiErr, source.Git.Validate())
+
+		return multiErr.ErrorOrNil()
+	}
+
 	if source.URL == "" {
 		multiErr = multierror.Append(multiErr, errors.New("source.url can't be empty"))
 	} else if _, err := url.Parse(source.URL); err != nil {
 		multiErr = multierror.Append(multiErr, fmt.Errorf("error parsing source.url %q: %w", source.URL, err))
 	}
 
-	if source.Destination == "" {
-		multiErr = multierror.Append(multiErr, errors.New("source.destination can't be empty"))
+	for _, mirror := range source.Mirrors {
+		if mirror == "" {
+			multiErr = multierror.Append(multiErr, errors.New("source.mirrors can't contain empty url"))
+		} else if _, err := url.Parse(mirror); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("error parsing source.mirrors %q: %w", mirror, err))
+		}
 	}
 
 	switch len(source.SHA256) {
@@ -80,10 +141,44 @@ func (source *Source) Validate() error {
 	return multiErr.ErrorOrNil()
 }
 
+func (source *Source) validateExtract() error {
+	var multiErr *multierror.Error
+
+	if source.Extract && source.IsGit() {
+		multiErr = multierror.Append(multiErr, errors.New("git source can't be extracted"))
+	}
+
+	if source.Extract && !source.IsGit() && !slices.ContainsFunc(extractableSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(strings.ToLower(source.Destination), suffix)
+	}) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("source.destination should be a tar archive (optionally compressed with gzip, bzip2, xz or zstd) to be extracted, one of %q: %q", extractableSuffixes, source.Destination))
+	}
+
+	if !source.Extract && (source.StripComponents != 0 || source.ExtractTo != "") {
+		multiErr = multierror.Append(multiErr, errors.New("source.stripComponents and source.extractTo require source.extract"))
+	}
+
+	if source.StripComponents < 0 {
+		multiErr = multierror.Append(multiErr, errors.New("source.stripComponents can't be negative"))
+	}
+
+	if source.ExtractTo != "" && !filepath.IsLocal(source.ExtractTo) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("source.extractTo should be a relative path within the step directory: %q", source.ExtractTo))
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
 // ValidateChecksums downloads the source, validates checksums,
 // and returns actual checksums and validation error, if any.
 func (source *Source) ValidateChecksums(ctx context.Context) (string, string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	return source.ValidateChecksumsFrom(ctx, source.URL)
+}
+
+// ValidateChecksumsFrom downloads the source from the given URL (which might be one of the mirrors),
+// validates checksums, and returns actual checksums and validation error, if any.
+func (source *Source) ValidateChecksumsFrom(ctx context.Context, sourceURL string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
 	if err != nil {
 		return "", "", err
 	}
@@ -49,3 +49,125 @@ func TestSourceValidateChecksums(t *testing.T) {
 	assert.Equal(t, expectedSHA256, actualSHA256)
 	assert.Equal(t, expectedSHA512, actualSHA512)
 }
+
+func TestSourceValidate(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		source        v1alpha2.Source
+		expectedError string
+	}{
+		{
+			name: "mirrors",
+			source: v1alpha2.Source{
+				URL:         "https://ftp.gnu.org/gnu/bison/bison-3.0.5.tar.xz",
+				Mirrors:     []string{"https://ftpmirror.gnu.org/gnu/bison/bison-3.0.5.tar.xz"},
+				Destination: "bison.tar.xz",
+				SHA256:      strings.Repeat("0", 64),
+				SHA512:      strings.Repeat("1", 128),
+			},
+		},
+		{
+			name: "empty mirror",
+			source: v1alpha2.Source{
+				URL:         "https://ftp.gnu.org/gnu/bison/bison-3.0.5.tar.xz",
+				Mirrors:     []string{""},
+				Destination: "bison.tar.xz",
+				SHA256:      strings.Repeat("0", 64),
+				SHA512:      strings.Repeat("1", 128),
+			},
+			expectedError: "source.mirrors can't contain empty url",
+		},
+		{
+			name: "strip components without extract",
+			source: v1alpha2.Source{
+				URL:             "https://ftp.gnu.org/gnu/bison/bison-3.0.5.tar.xz",
+				Destination:     "bison.tar.xz",
+				SHA256:          strings.Repeat("0", 64),
+				SHA512:          strings.Repeat("1", 128),
+				StripComponents: 1,
+			},
+			expectedError: "source.stripComponents and source.extractTo require source.extract",
+		},
+		{
+			name: "extract outside of step directory",
+			source: v1alpha2.Source{
+				URL:         "https://ftp.gnu.org/gnu/bison/bison-3.0.5.tar.xz",
+				Destination: "bison.tar.xz",
+				SHA256:      strings.Repeat("0", 64),
+				SHA512:      strings.Repeat("1", 128),
+				Extract:     true,
+				ExtractTo:   "../bison",
+			},
+			expectedError: `source.extractTo should be a relative path within the step directory: "../bison"`,
+		},
+		{
+			name: "extract",
+			source: v1alpha2.Source{
+				URL:             "https://ftp.gnu.org/gnu/bison/bison-3.0.5.tar.xz",
+				Destination:     "bison.tar.xz",
+				SHA256:          strings.Repeat("0", 64),
+				SHA512:          strings.Repeat("1", 128),
+				Extract:         true,
+				StripComponents: 1,
+			},
+		},
+		{
+			name: "extract zip",
+			source: v1alpha2.Source{
+				URL:         "https://github.com/protocolbuffers/protobuf/releases/download/v29.3/protoc-29.3-linux-x86_64.zip",
+				Destination: "protoc.zip",
+				SHA256:      strings.Repeat("0", 64),
+				SHA512:      strings.Repeat("1", 128),
+				Extract:     true,
+			},
+			expectedError: `source.destination should be a tar archive (optionally compressed with gzip, bzip2, xz or zstd) to be extracted`,
+		},
+		{
+			name: "git",
+			source: v1alpha2.Source{
+				Destination: "src",
+				Git: &v1alpha2.GitSource{
+					Repository: "https://github.com/siderolabs/bldr.git",
+					Ref:        "refs/tags/v0.6.3",
+					Commit:     "2dacbf722f363c10b43597f98eac7afa031701a9",
+				},
+			},
+		},
+		{
+			name: "git short commit",
+			source: v1alpha2.Source{
+				Destination: "src",
+				Git: &v1alpha2.GitSource{
+					Repository: "https://github.com/siderolabs/bldr.git",
+					Commit:     "2dacbf7",
+				},
+			},
+			expectedError: "source.git.commit should be a full commit hash (40 or 64 chars long)",
+		},
+		{
+			name: "git and url",
+			source: v1alpha2.Source{
+				URL:         "https://github.com/siderolabs/bldr/archive/v0.6.3.tar.gz",
+				Destination: "src",
+				SHA256:      strings.Repeat("0", 64),
+				Git: &v1alpha2.GitSource{
+					Commit: strings.Repeat("A", 40),
+				},
+			},
+			expectedError: "source can't have both url & git set",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.source.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// SSH describes forwarding of the SSH agent socket into the step.
+//
+// It can be set either to `true` to forward the default SSH agent,
+// or to a mapping to pick the SSH agent by ID.
+//
+//nolint:recvcheck
+type SSH struct {
+	// ID of the SSH agent in the build session, defaults to `default`.
+	ID       string `yaml:"id,omitempty"`
+	Enabled  bool   `yaml:"-"`
+	Required bool   `yaml:"required,omitempty"`
+}
+
+// GetID returns the SSH agent ID.
+func (ssh SSH) GetID() string {
+	if ssh.ID != "" {
+		return ssh.ID
+	}
+
+	return "default"
+}
+
+// IsZero implements yaml.IsZeroer interface.
+func (ssh SSH) IsZero() bool {
+	return !ssh.Enabled
+}
+
+// Validate the SSH forwarding.
+func (ssh SSH) Validate() error {
+	var multiErr *multierror.Error
+
+	if ssh.ID != "" && !secretIDRe.MatchString(ssh.ID) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("ssh.id should consist of letters, digits, '_', '.' and '-': %q", ssh.ID))
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// UnmarshalYAML implements yaml.Unmarshaller interface.
+func (ssh *SSH) UnmarshalYAML(unmarshal func(any) error) error {
+	var enabled bool
+
+	if err := unmarshal(&enabled); err == nil {
+		*ssh = SSH{Enabled: enabled}
+
+		return nil
+	}
+
+	type sshAlias SSH
+
+	var aux sshAlias
+
+	if err := unmarshal(&aux); err != nil {
+		return err
+	}
+
+	*ssh = SSH(aux)
+	ssh.Enabled = true
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaller interface.
+func (ssh SSH) MarshalYAML() (any, error) {
+	if ssh.ID == "" && !ssh.Required {
+		return ssh.Enabled, nil
+	}
+
+	type sshAlias SSH
+
+	return sshAlias(ssh), nil
+}
@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestStepSSH(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		yaml          string
+		expectedSSH   v1alpha2.SSH
+		expectedError string
+	}{
+		{
+			name: "disabled",
+			yaml: "network: default\n",
+		},
+		{
+			name:        "default agent",
+			yaml:        "network: default\nssh: true\n",
+			expectedSSH: v1alpha2.SSH{Enabled: true},
+		},
+		{
+			name:        "agent by id",
+			yaml:        "network: host\nssh:\n  id: github\n  required: true\n",
+			expectedSSH: v1alpha2.SSH{Enabled: true, ID: "github", Required: true},
+		},
+		{
+			name:          "no network",
+			yaml:          "ssh: true\n",
+			expectedSSH:   v1alpha2.SSH{Enabled: true},
+			expectedError: "ssh forwarding requires network mode other than none",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var step v1alpha2.Step
+
+			require.NoError(t, yaml.Unmarshal([]byte(test.yaml), &step))
+			assert.Equal(t, test.expectedSSH, step.SSH)
+
+			out, err := yaml.Marshal(step)
+			require.NoError(t, err)
+
+			var roundtrip v1alpha2.Step
+
+			require.NoError(t, yaml.Unmarshal(out, &roundtrip))
+			assert.Equal(t, step, roundtrip)
+
+			err = step.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
@@ -4,11 +4,23 @@
 
 package v1alpha2
 
-import "github.com/hashicorp/go-multierror"
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
 
 // Environment is a set of environment variables to be set in the step.
 type Environment map[string]string
 
+// userRe matches `uid` or `uid:gid`.
+var userRe = regexp.MustCompile(`^[0-9]+(:[0-9]+)?$`)
+
 // Steps is a collection of Step.
 type Steps []Step
 
@@ -26,12 +38,19 @@ func (steps Steps) Validate() error {
 // Step describes a single build step.
 //
 // Steps are executed sequentially, each step runs in its own
-// empty temporary directory.
+// empty temporary directory (unless WorkDir is set).
 type Step struct {
+	When       *Condition   `yaml:"when,omitempty"`
 	Env        Environment  `yaml:"env,omitempty"`
 	CachePaths []string     `yaml:"cachePaths,omitempty"`
-	TmpDir     string       `yaml:"-"`
+	Mounts     Mounts       `yaml:"mounts,omitempty"`
+	WorkDir    string       `yaml:"workdir,omitempty"`
+	User       string       `yaml:"user,omitempty"`
+	Shell      Shell        `yaml:"shell,omitempty"`
 	Sources    Sources      `yaml:"sources,omitempty"`
+	Patches    Patches      `yaml:"patches,omitempty"`
+	Secrets    Secrets      `yaml:"secrets,omitempty"`
+	SSH        SSH          `yaml:"ssh,omitempty"`
 	Prepare    Instructions `yaml:"prepare,omitempty"`
 	Build      Instructions `yaml:"build,omitempty"`
 	Install    Instructions `yaml:"install,omitempty"`
@@ -42,5 +61,48 @@ type Step struct {
 
 // Validate the step.
 func (step *Step) Validate() error {
-	return step.Sources.Validate()
+	var multiErr *multierror.Error
+
+	multiErr = multierror.Append(multiErr, step.Sources.Validate(), step.Patches.Validate(), step.Secrets.Validate(), step.Mounts.Validate(), step.When.Validate())
+
+	if step.WorkDir != "" && !filepath.IsAbs(step.WorkDir) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("step workdir should be absolute: %q", step.WorkDir))
+	}
+
+	if step.User != "" && !userRe.MatchString(step.User) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("step user should be in uid[:gid] format: %q", step.User))
+	}
+
+	if step.Shell != "" && !filepath.IsAbs(string(step.Shell)) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("step shell should be absolute: %q", step.Shell))
+	}
+
+	if step.SSH.Enabled {
+		multiErr = multierror.Append(multiErr, step.SSH.Validate())
+
+		if step.Network == NetworkModeNone {
+			multiErr = multierror.Append(multiErr, errors.New("ssh forwarding requires network mode other than none"))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// UIDGID returns numeric uid and gid of the step user, gid defaults to uid.
+//
+// It returns zeroes (root) if the user is not set or is invalid.
+func (step *Step) UIDGID() (uid, gid int) {
+	if !userRe.MatchString(step.User) {
+		return 0, 0
+	}
+
+	uidStr, gidStr, ok := strings.Cut(step.User, ":")
+	if !ok {
+		gidStr = uidStr
+	}
+
+	uid, _ = strconv.Atoi(uidStr) //nolint:errcheck
+	gid, _ = strconv.Atoi(gidStr) //nolint:errcheck
+
+	return uid, gid
 }
@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestStepValidateOverrides(t *testing.T) {
+	for _, test := range []struct {
+		name          string
+		step          v1alpha2.Step
+		expectedError string
+	}{
+		{
+			name: "valid",
+			step: v1alpha2.Step{
+				WorkDir: "/build",
+				User:    "1000:100",
+				Shell:   "/bin/bash",
+			},
+		},
+		{
+			name:          "relative workdir",
+			step:          v1alpha2.Step{WorkDir: "build"},
+			expectedError: `step workdir should be absolute: "build"`,
+		},
+		{
+			name:          "user name",
+			step:          v1alpha2.Step{User: "nobody"},
+			expectedError: `step user should be in uid[:gid] format: "nobody"`,
+		},
+		{
+			name:          "relative shell",
+			step:          v1alpha2.Step{Shell: "bash"},
+			expectedError: `step shell should be absolute: "bash"`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.step.Validate()
+
+			if test.expectedError == "" {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.expectedError)
+		})
+	}
+}
+
+func TestStepUIDGID(t *testing.T) {
+	for _, test := range []struct {
+		user        string
+		expectedUID int
+		expectedGID int
+	}{
+		{user: "", expectedUID: 0, expectedGID: 0},
+		{user: "1000", expectedUID: 1000, expectedGID: 1000},
+		{user: "1000:100", expectedUID: 1000, expectedGID: 100},
+		{user: "nobody", expectedUID: 0, expectedGID: 0},
+	} {
+		t.Run(test.user, func(t *testing.T) {
+			step := v1alpha2.Step{User: test.user}
+
+			uid, gid := step.UIDGID()
+
+			assert.Equal(t, test.expectedUID, uid)
+			assert.Equal(t, test.expectedGID, gid)
+		})
+	}
+}
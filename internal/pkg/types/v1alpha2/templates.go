@@ -5,12 +5,8 @@
 package v1alpha2
 
 import (
-	"bytes"
 	"fmt"
 	"strings"
-	"text/template"
-
-	"github.com/Masterminds/sprig/v3"
 
 	"github.com/siderolabs/bldr/internal/pkg/constants"
 )
@@ -23,21 +19,14 @@ type TemplatedFile struct {
 
 // AttachTemplatedFile attaches a templated file to the package context.
 func (p *Pkg) AttachTemplatedFile(path string, content []byte) error {
-	tmpl, err := template.New(path).
-		Funcs(sprig.HermeticTxtFuncMap()).
-		Parse(string(content))
+	rendered, err := p.Context.Render(path, content)
 	if err != nil {
-		return fmt.Errorf("failed to parse templated file %s: %w", path, err)
-	}
-
-	var buf bytes.Buffer
-	if err = tmpl.Execute(&buf, p.Context); err != nil {
 		return fmt.Errorf("failed to template file %s: %w", path, err)
 	}
 
 	p.TemplatedFiles = append(p.TemplatedFiles, TemplatedFile{
 		Path:    strings.TrimSuffix(path, constants.TemplateExt),
-		Content: buf.Bytes(),
+		Content: rendered,
 	})
 
 	return nil
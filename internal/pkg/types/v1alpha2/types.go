@@ -4,11 +4,80 @@
 
 package v1alpha2
 
-// Install is a list of Alpine package names to install.
-type Install []string
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/hashicorp/go-multierror"
+)
 
 // Finalize is a set of COPY instructions to finalize the build.
 type Finalize struct {
-	From string `yaml:"from,omitempty"`
-	To   string `yaml:"to,omitempty"`
+	When    *Condition `yaml:"when,omitempty"`
+	From    string     `yaml:"from,omitempty"`
+	To      string     `yaml:"to,omitempty"`
+	Mode    string     `yaml:"mode,omitempty"`
+	Include []string   `yaml:"include,omitempty"`
+	Exclude []string   `yaml:"exclude,omitempty"`
+	Owner   int        `yaml:"owner,omitempty"`
+	Group   int        `yaml:"group,omitempty"`
+}
+
+var (
+	octalModeRe    = regexp.MustCompile(`^[0-7]{3,4}$`)
+	symbolicModeRe = regexp.MustCompile(`^[ugoa]*[-+=][rwxXst]*(,[ugoa]*[-+=][rwxXst]*)*$`)
+)
+
+// GetMode returns either numeric mode or symbolic mode (e.g. `u-s,go-w`) of the copied files.
+//
+// If the mode is not set, zero mode and empty symbolic mode are returned.
+func (f *Finalize) GetMode() (mode os.FileMode, symbolic string) {
+	if !octalModeRe.MatchString(f.Mode) {
+		return 0, f.Mode
+	}
+
+	parsed, _ := strconv.ParseUint(f.Mode, 8, 32) //nolint:errcheck
+
+	return os.FileMode(parsed), ""
+}
+
+// Validate the finalize instruction.
+func (f *Finalize) Validate() error {
+	var multiErr *multierror.Error
+
+	multiErr = multierror.Append(multiErr, validatePatterns(f.Include, f.Exclude), f.When.Validate())
+
+	if f.Owner < 0 || f.Group < 0 {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("finalize owner and group should be non-negative: %d:%d", f.Owner, f.Group))
+	}
+
+	if f.Mode != "" && !octalModeRe.MatchString(f.Mode) && !symbolicModeRe.MatchString(f.Mode) {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("finalize mode should be octal (e.g. 0755) or symbolic (e.g. u-s,go-w): %q", f.Mode))
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// validatePatterns validates include and exclude glob patterns.
+func validatePatterns(include, exclude []string) error {
+	var multiErr *multierror.Error
+
+	for _, pattern := range slices.Concat(include, exclude) {
+		if pattern == "" {
+			multiErr = multierror.Append(multiErr, errors.New("empty include/exclude pattern"))
+
+			continue
+		}
+
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("invalid include/exclude pattern %q: %w", pattern, err))
+		}
+	}
+
+	return multiErr.ErrorOrNil()
 }
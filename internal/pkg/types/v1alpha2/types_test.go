@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestFinalizeValidate(t *testing.T) {
+	require.NoError(t, (&v1alpha2.Finalize{
+		From:    "/rootfs",
+		To:      "/",
+		Exclude: []string{"usr/share/man", "**/*.la"},
+	}).Validate())
+
+	err := (&v1alpha2.Finalize{
+		From:    "/rootfs",
+		Include: []string{"usr/lib/[", ""},
+	}).Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid include/exclude pattern "usr/lib/["`)
+	assert.Contains(t, err.Error(), "empty include/exclude pattern")
+}
+
+func TestDependencyValidateFilters(t *testing.T) {
+	dep := v1alpha2.Dependency{
+		Stage:   "gcc",
+		Include: []string{"*.so"},
+	}
+
+	require.NoError(t, dep.Validate())
+	assert.True(t, dep.HasFilters())
+
+	dep.Exclude = []string{"["}
+	require.Error(t, dep.Validate())
+}
+
+func TestFinalizeMode(t *testing.T) {
+	for _, test := range []struct {
+		mode             string
+		expectedMode     os.FileMode
+		expectedSymbolic string
+		expectedError    string
+	}{
+		{mode: ""},
+		{mode: "0755", expectedMode: 0o755},
+		{mode: "4750", expectedMode: 0o4750},
+		{mode: "u-s,g-s", expectedSymbolic: "u-s,g-s"},
+		{mode: "rwx", expectedError: `finalize mode should be octal (e.g. 0755) or symbolic (e.g. u-s,go-w): "rwx"`},
+		{mode: "0999", expectedError: `finalize mode should be octal`},
+	} {
+		t.Run(test.mode, func(t *testing.T) {
+			fin := v1alpha2.Finalize{Mode: test.mode}
+
+			err := fin.Validate()
+			if test.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			mode, symbolic := fin.GetMode()
+			assert.Equal(t, test.expectedMode, mode)
+			assert.Equal(t, test.expectedSymbolic, symbolic)
+		})
+	}
+}
+
+func TestFinalizeValidateOwner(t *testing.T) {
+	require.NoError(t, (&v1alpha2.Finalize{Owner: 1000, Group: 1000}).Validate())
+	require.Error(t, (&v1alpha2.Finalize{Owner: -1}).Validate())
+}
@@ -0,0 +1,223 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-multierror"
+	"go.yaml.in/yaml/v4"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+// VariableDeclaration declares a variable used in the templates.
+type VariableDeclaration struct {
+	// Default value is used if the variable is not set, it might be a string, a list or a map.
+	Default     any    `yaml:"default,omitempty"`
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	// Pattern is a regular expression which the whole value should match.
+	//
+	// For lists, each item should match the pattern.
+	Pattern  string `yaml:"pattern,omitempty"`
+	Required bool   `yaml:"required,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+//
+// Default value is decoded the same way as variables (scalars are kept as strings).
+func (decl *VariableDeclaration) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		Default     yaml.Node `yaml:"default"`
+		Name        string    `yaml:"name"`
+		Description string    `yaml:"description"`
+		Pattern     string    `yaml:"pattern"`
+		Required    bool      `yaml:"required"`
+	}
+
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	*decl = VariableDeclaration{
+		Name:        raw.Name,
+		Description: raw.Description,
+		Pattern:     raw.Pattern,
+		Required:    raw.Required,
+	}
+
+	if raw.Default.Kind != 0 {
+		value, err := types.DecodeValue(&raw.Default)
+		if err != nil {
+			return err
+		}
+
+		decl.Default = value
+	}
+
+	return nil
+}
+
+// Validate the declaration.
+func (decl *VariableDeclaration) Validate() error {
+	var multiErr *multierror.Error
+
+	if decl.Name == "" {
+		multiErr = multierror.Append(multiErr, errors.New("variable name can't be empty"))
+	}
+
+	if decl.Required && decl.Default != nil {
+		multiErr = multierror.Append(multiErr, fmt.Errorf("variable %q: required variable can't have a default value", decl.Name))
+	}
+
+	if decl.Pattern != "" {
+		re, err := decl.compilePattern()
+		if err != nil {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable %q: invalid pattern %q: %w", decl.Name, decl.Pattern, err))
+		} else if decl.Default != nil {
+			if err = matchPattern(re, decl.Pattern, decl.Default); err != nil {
+				multiErr = multierror.Append(multiErr, fmt.Errorf("variable %q: invalid default value: %w", decl.Name, err))
+			}
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Check the value of the variable in the context.
+func (decl *VariableDeclaration) Check(vars types.Variables) error {
+	value, ok := vars[decl.Name]
+	if !ok || isUnset(value) {
+		if !decl.Required {
+			return nil
+		}
+
+		if decl.Description != "" {
+			return fmt.Errorf("variable %q is required, but not set (%s)", decl.Name, decl.Description)
+		}
+
+		return fmt.Errorf("variable %q is required, but not set", decl.Name)
+	}
+
+	if decl.Pattern == "" {
+		return nil
+	}
+
+	re, err := decl.compilePattern()
+	if err != nil {
+		return err
+	}
+
+	if err = matchPattern(re, decl.Pattern, value); err != nil {
+		return fmt.Errorf("variable %q: %w", decl.Name, err)
+	}
+
+	return nil
+}
+
+// isUnset checks whether the value is empty: empty strings, lists and maps are treated as not set.
+func isUnset(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case string:
+		return value == ""
+	case []any:
+		return len(value) == 0
+	case map[string]any:
+		return len(value) == 0
+	default:
+		return false
+	}
+}
+
+func (decl *VariableDeclaration) compilePattern() (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + decl.Pattern + ")$")
+}
+
+func matchPattern(re *regexp.Regexp, pattern string, value any) error {
+	switch value := value.(type) {
+	case string:
+		if !re.MatchString(value) {
+			return fmt.Errorf("value %q doesn't match pattern %q", value, pattern)
+		}
+	case []any:
+		var multiErr *multierror.Error
+
+		for _, item := range value {
+			if err := matchPattern(re, pattern, item); err != nil {
+				multiErr = multierror.Append(multiErr, err)
+			}
+		}
+
+		return multiErr.ErrorOrNil()
+	default:
+		return fmt.Errorf("value of type %T can't be matched against pattern %q", value, pattern)
+	}
+
+	return nil
+}
+
+// VariableDeclarations is a list of variable declarations (variable schema).
+type VariableDeclarations []VariableDeclaration
+
+// Validate the declarations.
+func (decls VariableDeclarations) Validate() error {
+	var multiErr *multierror.Error
+
+	names := map[string]struct{}{}
+
+	for _, decl := range decls {
+		if err := decl.Validate(); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+
+		if _, exists := names[decl.Name]; exists {
+			multiErr = multierror.Append(multiErr, fmt.Errorf("variable %q is declared more than once", decl.Name))
+		}
+
+		names[decl.Name] = struct{}{}
+	}
+
+	return multiErr.ErrorOrNil()
+}
+
+// Defaults returns default values of the declared variables which are not set.
+func (decls VariableDeclarations) Defaults(vars types.Variables) types.Variables {
+	defaults := types.Variables{}
+
+	for _, decl := range decls {
+		if _, ok := vars[decl.Name]; ok || decl.Default == nil {
+			continue
+		}
+
+		defaults[decl.Name] = decl.Default
+	}
+
+	return defaults
+}
+
+// ApplyDefaults sets default values of the variables which are not set.
+func (decls VariableDeclarations) ApplyDefaults(vars types.Variables) {
+	vars.Merge(decls.Defaults(vars))
+}
+
+// Check the variables against the declarations.
+//
+// All missing required variables and values which don't match the pattern are reported.
+func (decls VariableDeclarations) Check(vars types.Variables) error {
+	var multiErr *multierror.Error
+
+	for _, decl := range decls {
+		if err := decl.Check(vars); err != nil {
+			multiErr = multierror.Append(multiErr, err)
+		}
+	}
+
+	return multiErr.ErrorOrNil()
+}
@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package v1alpha2_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+	"github.com/siderolabs/bldr/internal/pkg/types/v1alpha2"
+)
+
+func TestVariableDeclarations(t *testing.T) {
+	pkgfile, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+variables:
+  - name: VERSION
+    description: version of the package
+    required: true
+    pattern: v[0-9.]+
+  - name: GO_VERSION
+    default: 1.20
+  - name: FLAGS
+    default:
+      - --enable-static
+    pattern: --[a-z-]+
+  - name: OPTIONAL
+  - name: TRIPLETS
+    required: true
+  - name: PATCHES
+    required: true
+`))
+	require.NoError(t, err)
+
+	vars := types.Variables{"FLAGS": []any{"--disable-nls"}, "TRIPLETS": map[string]any{}, "PATCHES": []any{}}
+	pkgfile.Variables.ApplyDefaults(vars)
+
+	assert.Equal(t, types.Variables{"GO_VERSION": "1.20", "FLAGS": []any{"--disable-nls"}, "TRIPLETS": map[string]any{}, "PATCHES": []any{}}, vars)
+
+	err = pkgfile.CheckVariables(vars)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `variable "VERSION" is required, but not set (version of the package)`)
+	// empty lists and maps are not set
+	assert.Contains(t, err.Error(), `variable "TRIPLETS" is required, but not set`)
+	assert.Contains(t, err.Error(), `variable "PATCHES" is required, but not set`)
+
+	vars["TRIPLETS"] = map[string]any{"amd64": "x86_64-linux-musl"}
+	vars["PATCHES"] = []any{"fix.patch"}
+
+	vars["VERSION"] = "1.2"
+	vars["FLAGS"] = []any{"--enable-static", "-O2"}
+
+	err = pkgfile.CheckVariables(vars)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `variable "VERSION": value "1.2" doesn't match pattern "v[0-9.]+"`)
+	assert.Contains(t, err.Error(), `variable "FLAGS": 1 error occurred:`)
+	assert.Contains(t, err.Error(), `value "-O2" doesn't match pattern "--[a-z-]+"`)
+
+	vars["VERSION"] = "v1.2"
+	vars["FLAGS"] = []any{"--enable-static"}
+
+	require.NoError(t, pkgfile.CheckVariables(vars))
+
+	// Pkgfile is optional
+	require.NoError(t, (*v1alpha2.Pkgfile)(nil).CheckVariables(types.Variables{}))
+}
+
+func TestVariableDeclarationsValidate(t *testing.T) {
+	_, err := v1alpha2.NewPkgfile([]byte(`format: v1alpha2
+variables:
+  - description: no name
+  - name: TAG
+    required: true
+    default: latest
+  - name: TAG
+  - name: ARCH
+    pattern: "(amd64"
+  - name: OS
+    default: darwin
+    pattern: linux
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `variable name can't be empty`)
+	assert.Contains(t, err.Error(), `variable "TAG": required variable can't have a default value`)
+	assert.Contains(t, err.Error(), `variable "TAG" is declared more than once`)
+	assert.Contains(t, err.Error(), `variable "ARCH": invalid pattern "(amd64"`)
+	assert.Contains(t, err.Error(), `variable "OS": invalid default value: value "darwin" doesn't match pattern "linux"`)
+}
@@ -4,51 +4,79 @@
 
 package v1alpha2
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"regexp"
 
-// Variant is a kind of base build image.
+	"github.com/siderolabs/bldr/internal/pkg/constants"
+)
+
+// Variant is a name of the base build image.
 //
-//nolint:recvcheck
-type Variant int
+// Variants `alpine` and `scratch` are built-in, other variants are defined in the Pkgfile.
+type Variant string
 
 const (
 	// Unset is a variant that is not set.
-	Unset Variant = iota
+	Unset Variant = ""
 	// Alpine variant uses Alpine as base image for the build.
-	Alpine
+	Alpine Variant = "alpine"
 	// Scratch variant uses scratch image as base image for the build.
-	Scratch
+	Scratch Variant = "scratch"
 )
 
+var variantNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
 func (v Variant) String() string {
-	return []string{"unset", "alpine", "scratch"}[v]
+	return string(v)
 }
 
-// UnmarshalYAML implements yaml.Unmarshaller interface.
-func (v *Variant) UnmarshalYAML(unmarshal func(any) error) error {
-	var aux string
-
-	if err := unmarshal(&aux); err != nil {
-		return err
+// Validate the variant name.
+func (v Variant) Validate() error {
+	if !variantNameRe.MatchString(string(v)) {
+		return fmt.Errorf("variant name %q is invalid, it should match %s", v, variantNameRe)
 	}
 
-	var val Variant
+	return nil
+}
 
-	switch aux {
-	case Alpine.String():
-		val = Alpine
-	case Scratch.String():
-		val = Scratch
-	default:
-		return fmt.Errorf("unknown variant %q", aux)
+// VariantDefinition describes the base build image of the variant.
+type VariantDefinition struct {
+	// Image is the base image reference, empty image means scratch.
+	Image string `yaml:"image,omitempty"`
+	// Setup commands are executed on top of the image (without shell).
+	Setup []string `yaml:"setup,omitempty"`
+	// PackageManager installs the packages listed in the `install` section of the pkg.yaml.
+	PackageManager PackageManager `yaml:"packageManager,omitempty"`
+	// Install is the command of the `custom` package manager (without shell), packages are appended to it.
+	Install []string `yaml:"install,omitempty"`
+}
+
+// Validate the variant definition.
+func (def *VariantDefinition) Validate() error {
+	if def.Image == "" && (len(def.Setup) > 0 || def.PackageManager != PackageManagerNone) {
+		return errors.New("variant without image can't have setup commands or package manager")
 	}
 
-	*v = val
+	if (def.PackageManager == PackageManagerCustom) != (len(def.Install) > 0) {
+		return fmt.Errorf("install command should be set if and only if package manager is %s", PackageManagerCustom)
+	}
 
-	return nil
+	return def.PackageManager.Validate()
 }
 
-// MarshalYAML implements yaml.Marshaller interface.
-func (v Variant) MarshalYAML() (any, error) {
-	return v.String(), nil
+// BuiltinVariants returns definitions of the built-in variants.
+func BuiltinVariants() map[Variant]VariantDefinition {
+	return map[Variant]VariantDefinition{
+		Alpine: {
+			Image: constants.DefaultBaseImage,
+			Setup: []string{
+				"apk --no-cache --update add bash",
+				"ln -svf /bin/bash /bin/sh",
+			},
+			PackageManager: PackageManagerAPK,
+		},
+		Scratch: {},
+	}
 }
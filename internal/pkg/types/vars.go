@@ -6,70 +6,226 @@
 package types
 
 import (
-	"bytes"
+	"fmt"
 	"maps"
+	"os"
 	"path/filepath"
-	"text/template"
+	"slices"
+	"strings"
 
-	"github.com/Masterminds/sprig/v3"
 	"go.yaml.in/yaml/v4"
 )
 
 // Variables presents generic variables for templating/environment.
 //
+// Values are either strings (YAML scalars are kept as strings), lists ([]any) or maps (map[string]any),
+// so that they can be used with `range`/`index` in templates.
+//
 //nolint:recvcheck
-type Variables map[string]string
+type Variables map[string]any
+
+// AppendSuffix is a suffix of the variable name which appends the list to the existing list on Merge.
+const AppendSuffix = "+"
 
 // Merge two Variables in place.
+//
+// Merge rules:
+//   - maps are merged recursively;
+//   - lists and scalars replace the existing value;
+//   - a variable with the name suffixed with `+` (e.g. `FLAGS+`) appends the list to the existing list.
 func (v Variables) Merge(other Variables) Variables {
-	for key := range other {
-		v[key] = other[key]
-	}
+	mergeMap(v, other)
 
 	return v
 }
 
+func mergeMap(dst, src map[string]any) {
+	for key, value := range src {
+		if name, ok := strings.CutSuffix(key, AppendSuffix); ok {
+			existing, _ := dst[name].([]any) //nolint:errcheck
+			appended, isList := value.([]any)
+
+			if !isList {
+				appended = []any{value}
+			}
+
+			dst[name] = slices.Concat(existing, deepCopy(appended).([]any))
+
+			continue
+		}
+
+		if srcMap, ok := value.(map[string]any); ok {
+			if dstMap, ok := dst[key].(map[string]any); ok {
+				mergeMap(dstMap, srcMap)
+
+				continue
+			}
+		}
+
+		dst[key] = deepCopy(value)
+	}
+}
+
 // Copy the Variables.
 func (v Variables) Copy() Variables {
-	result := make(Variables, len(v))
+	if v == nil {
+		return Variables{}
+	}
+
+	return Variables(deepCopy(map[string]any(v)).(map[string]any)) //nolint:forcetypeassert
+}
+
+func deepCopy(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(value))
+
+		for key, item := range value {
+			result[key] = deepCopy(item)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(value))
 
-	maps.Copy(result, v)
+		for i, item := range value {
+			result[i] = deepCopy(item)
+		}
 
-	return result
+		return result
+	default:
+		return value
+	}
+}
+
+// GetString returns the scalar value of the variable, or empty string if the variable is not set or not a scalar.
+func (v Variables) GetString(key string) string {
+	s, _ := v[key].(string) //nolint:errcheck
+
+	return s
+}
+
+// Environment returns scalar variables, which can be exported to the environment.
+func (v Variables) Environment() map[string]string {
+	env := make(map[string]string, len(v))
+
+	for key, value := range v {
+		if s, ok := value.(string); ok {
+			env[key] = s
+		}
+	}
+
+	return env
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+//
+// Scalar values are kept as strings (e.g. `1.20` is not converted to a number).
+func (v *Variables) UnmarshalYAML(node *yaml.Node) error {
+	value, err := decodeNode(node)
+	if err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		if *v == nil {
+			*v = Variables{}
+		}
+
+		maps.Copy(*v, value)
+	case string:
+		if value != "" {
+			return fmt.Errorf("line %d: variables should be a map", node.Line)
+		}
+	default:
+		return fmt.Errorf("line %d: variables should be a map", node.Line)
+	}
+
+	return nil
+}
+
+// DecodeValue decodes a single variable value from the YAML node.
+//
+// Scalar values are kept as strings, sequences are decoded as []any, and mappings as map[string]any.
+func DecodeValue(node *yaml.Node) (any, error) {
+	return decodeNode(node)
+}
+
+func decodeNode(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return "", nil
+		}
+
+		return decodeNode(node.Content[0])
+	case yaml.AliasNode:
+		return decodeNode(node.Alias)
+	case yaml.SequenceNode:
+		result := make([]any, 0, len(node.Content))
+
+		for _, item := range node.Content {
+			value, err := decodeNode(item)
+			if err != nil {
+				return nil, err
+			}
+
+			result = append(result, value)
+		}
+
+		return result, nil
+	case yaml.MappingNode:
+		result := make(map[string]any, len(node.Content)/2)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+			if keyNode.Kind != yaml.ScalarNode {
+				return nil, fmt.Errorf("line %d: variable name should be a scalar", keyNode.Line)
+			}
+
+			value, err := decodeNode(valueNode)
+			if err != nil {
+				return nil, err
+			}
+
+			result[keyNode.Value] = value
+		}
+
+		return result, nil
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return "", nil
+		}
+
+		return node.Value, nil
+	default:
+		return nil, fmt.Errorf("line %d: unsupported variable value", node.Line)
+	}
 }
 
 // Load the variables from YAML with the given context.
 func (v *Variables) Load(path string, context Variables) error {
-	tmpl, err := template.New(filepath.Base(path)).
-		Funcs(sprig.HermeticTxtFuncMap()).
-		ParseFiles(path)
+	contents, err := os.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	var buf bytes.Buffer
-
-	if err = tmpl.Execute(&buf, context); err != nil {
+	rendered, err := context.Render(filepath.Base(path), contents)
+	if err != nil {
 		return err
 	}
 
-	return yaml.Unmarshal(buf.Bytes(), v)
+	return yaml.Unmarshal(rendered, v)
 }
 
 // LoadContents the variables from byte slice with the given context.
 func (v *Variables) LoadContents(contents []byte, context Variables) error {
-	tmpl, err := template.New("vars").
-		Funcs(sprig.HermeticTxtFuncMap()).
-		Parse(string(contents))
+	rendered, err := context.Render("vars", contents)
 	if err != nil {
 		return err
 	}
 
-	var buf bytes.Buffer
-
-	if err = tmpl.Execute(&buf, context); err != nil {
-		return err
-	}
-
-	return yaml.Unmarshal(buf.Bytes(), v)
+	return yaml.Unmarshal(rendered, v)
 }
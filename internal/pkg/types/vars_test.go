@@ -0,0 +1,68 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package types_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/bldr/internal/pkg/types"
+)
+
+func TestVariablesLoadContents(t *testing.T) {
+	var vars types.Variables
+
+	require.NoError(t, vars.LoadContents([]byte(`GO_VERSION: 1.20
+ENABLED: true
+EMPTY:
+FLAGS:
+  - --with-{{ .FEATURE }}
+  - 1
+TRIPLETS:
+  amd64: x86_64-linux-musl
+`), types.Variables{"FEATURE": "zstd"}))
+
+	assert.Equal(t, types.Variables{
+		"GO_VERSION": "1.20",
+		"ENABLED":    "true",
+		"EMPTY":      "",
+		"FLAGS":      []any{"--with-zstd", "1"},
+		"TRIPLETS":   map[string]any{"amd64": "x86_64-linux-musl"},
+	}, vars)
+
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.20", "ENABLED": "true", "EMPTY": ""}, vars.Environment())
+	assert.Equal(t, "1.20", vars.GetString("GO_VERSION"))
+	assert.Empty(t, vars.GetString("FLAGS"))
+
+	require.Error(t, vars.LoadContents([]byte(`- A`), nil))
+}
+
+func TestVariablesMerge(t *testing.T) {
+	base := types.Variables{
+		"A":        "a",
+		"FLAGS":    []any{"--a"},
+		"TRIPLETS": map[string]any{"amd64": "x86_64", "arm64": "aarch64"},
+	}
+
+	vars := base.Copy().Merge(types.Variables{
+		"A":        []any{"list"},
+		"FLAGS+":   []any{"--b"},
+		"EXTRA+":   "--c",
+		"TRIPLETS": map[string]any{"arm64": "arm64", "riscv64": "riscv64"},
+	})
+
+	assert.Equal(t, types.Variables{
+		"A":        []any{"list"},
+		"FLAGS":    []any{"--a", "--b"},
+		"EXTRA":    []any{"--c"},
+		"TRIPLETS": map[string]any{"amd64": "x86_64", "arm64": "arm64", "riscv64": "riscv64"},
+	}, vars)
+
+	// base is not modified
+	assert.Equal(t, []any{"--a"}, base["FLAGS"])
+	assert.Equal(t, map[string]any{"amd64": "x86_64", "arm64": "aarch64"}, base["TRIPLETS"])
+}
@@ -64,7 +64,12 @@ func convertSteps(old []*v1alpha1.Step) []v1alpha2.Step {
 		}
 
 		for _, src := range step.Sources {
-			newStep.Sources = append(newStep.Sources, v1alpha2.Source(*src))
+			newStep.Sources = append(newStep.Sources, v1alpha2.Source{
+				URL:         src.URL,
+				Destination: src.Destination,
+				SHA256:      src.SHA256,
+				SHA512:      src.SHA512,
+			})
 		}
 
 		newSteps = append(newSteps, newStep)
@@ -75,7 +80,10 @@ func convertSteps(old []*v1alpha1.Step) []v1alpha2.Step {
 
 func convertFinalize(old []*v1alpha1.Finalize) []v1alpha2.Finalize {
 	return xslices.Map(old, func(f *v1alpha1.Finalize) v1alpha2.Finalize {
-		return v1alpha2.Finalize(*f)
+		return v1alpha2.Finalize{
+			From: f.From,
+			To:   f.To,
+		}
 	})
 }
 
@@ -87,11 +95,11 @@ func FromV1Alpha1(oldPkg *v1alpha1.Pkg, stageNames []string) *v1alpha2.Pkg {
 
 	return &v1alpha2.Pkg{
 		Name:         oldPkg.Name,
-		Install:      v1alpha2.Install(oldPkg.Install),
+		Install:      v1alpha2.Install{Packages: oldPkg.Install},
 		Dependencies: convertDeps(stageNames, oldPkg.Dependencies),
 		Steps:        convertSteps(oldPkg.Steps),
 		Finalize:     convertFinalize(oldPkg.Finalize),
-		Variant:      v1alpha2.Variant(oldPkg.Variant),
+		Variant:      v1alpha2.Variant(oldPkg.Variant.String()),
 		Shell:        v1alpha2.Shell(oldPkg.Shell),
 	}
 }
@@ -13,13 +13,25 @@ import (
 type EvalRunner struct {
 	CommandRunner
 
-	Target   string
-	Template string
+	Target        string
+	Template      string
+	Platform      string
+	BuildPlatform string
 }
 
 // Run implements Run interface.
 func (runner EvalRunner) Run(t *testing.T) {
-	cmd := exec.CommandContext(t.Context(), "bldr", "eval", "--target", runner.Target, runner.Template)
+	args := []string{"eval", "--target", runner.Target}
+
+	if runner.Platform != "" {
+		args = append(args, "--target-platform", runner.Platform)
+	}
+
+	if runner.BuildPlatform != "" {
+		args = append(args, "--build-platform", runner.BuildPlatform)
+	}
+
+	cmd := exec.CommandContext(t.Context(), "bldr", append(args, runner.Template)...)
 
 	runner.run(t, cmd, "bldr eval")
 }
@@ -17,14 +17,20 @@ type TestManifest struct {
 
 // RunManifest describes single run of integration test.
 type RunManifest struct {
-	Name         string  `yaml:"name"`
-	Runner       string  `yaml:"runner"`
-	Platform     string  `yaml:"platform"`
-	Target       string  `yaml:"target"`
-	Expect       string  `yaml:"expect"`
-	ExpectStdout *string `yaml:"expectStdout"`
-	CreateFile   string  `yaml:"createFile"`
-	Template     string  `yaml:"template"`
+	Name          string  `yaml:"name"`
+	Runner        string  `yaml:"runner"`
+	Platform      string  `yaml:"platform"`
+	BuildPlatform string  `yaml:"buildPlatform"`
+	Target        string  `yaml:"target"`
+	Expect        string  `yaml:"expect"`
+	ExpectStdout  *string `yaml:"expectStdout"`
+	CreateFile    string  `yaml:"createFile"`
+	Template      string  `yaml:"template"`
+	Explain       string  `yaml:"explain"`
+	File          string  `yaml:"file"`
+	Variable      string  `yaml:"variable"`
+
+	ExpectFiles map[string]string `yaml:"expectFiles"`
 }
 
 // NewTestManifest loads TestManifest from test.yaml file.
@@ -67,12 +67,33 @@ func getRunner(manifest RunManifest) (Run, error) {
 		}, nil
 	case "eval":
 		return EvalRunner{
+			CommandRunner: CommandRunner{
+				Expect:       manifest.Expect,
+				ExpectStdout: manifest.ExpectStdout,
+			},
+			Target:        manifest.Target,
+			Template:      manifest.Template,
+			Platform:      manifest.Platform,
+			BuildPlatform: manifest.BuildPlatform,
+		}, nil
+	case "vars":
+		return VarsRunner{
 			CommandRunner: CommandRunner{
 				Expect:       manifest.Expect,
 				ExpectStdout: manifest.ExpectStdout,
 			},
 			Target:   manifest.Target,
-			Template: manifest.Template,
+			Explain:  manifest.Explain,
+			Platform: manifest.Platform,
+		}, nil
+	case "update":
+		return UpdateRunner{
+			CommandRunner: CommandRunner{
+				Expect: manifest.Expect,
+			},
+			File:        manifest.File,
+			Variable:    manifest.Variable,
+			ExpectFiles: manifest.ExpectFiles,
 		}, nil
 	case "validate":
 		return ValidateRunner{
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package testutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// UpdateRunner runs bldr update against the local HTTP server.
+//
+// The server responds with the request path, so the checksums are known in advance,
+// its URL is passed as the MIRROR build argument.
+type UpdateRunner struct {
+	CommandRunner
+
+	File        string
+	Variable    string
+	ExpectFiles map[string]string
+}
+
+// Run implements Run interface.
+func (runner UpdateRunner) Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path)) //nolint:errcheck
+	}))
+	defer server.Close()
+
+	cmd := exec.CommandContext(t.Context(), "bldr", "update", "--build-arg", "MIRROR="+server.URL, runner.File, runner.Variable)
+
+	runner.run(t, cmd, "bldr update")
+
+	for path, expected := range runner.ExpectFiles {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("error reading %q: %v", path, err)
+		}
+
+		if expected != string(contents) {
+			t.Fatalf("%q contents mismatch: %q != %q", path, expected, string(contents))
+		}
+	}
+}
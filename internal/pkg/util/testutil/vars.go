@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package testutil
+
+import (
+	"os/exec"
+	"testing"
+)
+
+// VarsRunner runs bldr vars.
+type VarsRunner struct {
+	CommandRunner
+
+	Target   string
+	Explain  string
+	Platform string
+}
+
+// Run implements Run interface.
+func (runner VarsRunner) Run(t *testing.T) {
+	args := []string{"vars", "--target", runner.Target}
+
+	if runner.Explain != "" {
+		args = append(args, "--explain", runner.Explain)
+	}
+
+	if runner.Platform != "" {
+		args = append(args, "--target-platform", runner.Platform)
+	}
+
+	cmd := exec.CommandContext(t.Context(), "bldr", args...)
+
+	runner.run(t, cmd, "bldr vars")
+}